@@ -1,11 +1,13 @@
 package main
 
 import (
+	"database/sql"
 	"flag"
 	"net/http"
 
 	h "github.com/tboddyspargo/fetch/handler"
 	"github.com/tboddyspargo/fetch/log"
+	"github.com/tboddyspargo/fetch/points"
 )
 
 // main is the primary executor for this executable package.
@@ -14,12 +16,62 @@ import (
 func main() {
 	logpath := flag.String("log-path", log.DefaultLogPath, "The path (directory or file name) where logs will be written. If a directory is provided, default file name with appended date will be used - one log file per day.")
 	port := flag.String("port", "8080", "The port to listen on.")
+	store := flag.String("store", "memory", "The persistence backend to use for points data: \"memory\", \"sql\", or \"journal\".")
+	driver := flag.String("sql-driver", "postgres", "The database/sql driver name to use when --store=sql. The driver must be registered via a blank import in this package.")
+	dsn := flag.String("sql-dsn", "", "The data source name (connection string) to use when --store=sql.")
+	journalDir := flag.String("journal-dir", "./data", "The directory holding journal.log and checkpoint.json when --store=journal.")
+	authConfigPath := flag.String("auth-config", "", "Path to a JSON handler.AuthConfig file enabling OAuth2/OIDC bearer-token auth on the transaction and spend routes. Left unauthenticated when unset.")
+	configToken := flag.String("config-token", "", "Bearer token required to read or patch /config. Left unauthenticated when unset.")
 	flag.Parse()
 	log.SetOutputPath(*logpath)
+	h.SetConfigToken(*configToken)
+
+	switch *store {
+	case "sql":
+		db, err := sql.Open(*driver, *dsn)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sqlStore, err := points.NewSQLStore(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		points.SetStore(sqlStore)
+	case "journal":
+		journalStore, err := points.NewJournalStore(*journalDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		points.SetStore(journalStore)
+	}
+
+	var transactionHandler, spendHandler http.HandlerFunc = h.AddTransactionHandler, h.SpendPointsHandler
+	if *authConfigPath != "" {
+		authConfig, err := h.LoadConnectorConfig(*authConfigPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		connector, err := h.NewConnector(authConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		transactionHandler = h.AuthMiddleware(connector, transactionHandler)
+		spendHandler = h.AuthMiddleware(connector, spendHandler)
+	}
 
 	http.HandleFunc("/health-check", h.HealthCheckHandler)
-	http.HandleFunc("/transaction", h.AddTransactionHandler)
-	http.HandleFunc("/spend", h.SpendPointsHandler)
-	http.HandleFunc("/payer-points", h.PayerPointsHandler)
+	http.HandleFunc("/healthz", h.HealthzHandler)
+	http.HandleFunc("/healthz/live", h.HealthzHandler)
+	http.HandleFunc("/healthz/ready", h.ReadyzHandler)
+	http.HandleFunc("/readyz", h.ReadyzHandler)
+	http.HandleFunc("/metrics", h.MetricsHandler)
+	http.HandleFunc("/config", h.ConfigHandler)
+	http.HandleFunc("/transaction", h.Instrumented("AddTransactionHandler", transactionHandler))
+	http.HandleFunc("/transactions", h.ListTransactionsHandler)
+	http.HandleFunc("/transactions/", h.ReverseTransactionHandler)
+	http.HandleFunc("/spend", h.Instrumented("SpendPointsHandler", spendHandler))
+	http.HandleFunc("/spend/simulate", h.SimulateSpendHandler)
+	http.HandleFunc("/spend-history", h.SpendHistoryHandler)
+	http.HandleFunc("/payer-points", h.Instrumented("PayerPointsHandler", h.PayerPointsHandler))
 	log.Fatal(http.ListenAndServe(":"+*port, nil))
 }
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Identity is the authenticated caller attached to a request's context by
+// AuthMiddleware. AllowedPayers lists the payer names this caller may
+// create transactions for; AddTransactionHandler checks it via
+// authorizePayer, and no other handler consults it. A nil or empty slice means this
+// Identity is scoped to no payers at all - e.g. a GitHub user in none of
+// OrgPayerScopes's orgs, or an OIDC token with an empty "payers" claim - not
+// "all payers"; that fallback only applies when there's no Identity on the
+// request's context to begin with (see authorizePayer).
+type Identity struct {
+	Subject       string
+	AllowedPayers []string
+}
+
+// allows reports whether payer is in id's AllowedPayers. An Identity with
+// no AllowedPayers is scoped to nothing, not everything - see authorizePayer
+// for the "no Identity at all" case that actually means unrestricted.
+func (id Identity) allows(payer string) bool {
+	for _, p := range id.AllowedPayers {
+		if p == payer {
+			return true
+		}
+	}
+	return false
+}
+
+// Connector authenticates a bearer token against an identity provider and
+// returns the Identity it maps to. GitHubConnector and OIDCConnector are the
+// two shipped implementations; AuthConfig/NewConnector select between them.
+type Connector interface {
+	Authenticate(ctx context.Context, token string) (Identity, error)
+}
+
+// identityContextKey is the context.Context key AuthMiddleware stores the
+// authenticated Identity under.
+type identityContextKey struct{}
+
+// identityFromContext returns the Identity AuthMiddleware attached to ctx,
+// if any.
+func identityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityContextKey{}).(Identity)
+	return id, ok
+}
+
+// AuthMiddleware wraps next behind an OAuth2/OIDC bearer-token check: it
+// extracts the token from the Authorization header, authenticates it
+// against connector, and attaches the resulting Identity to the request's
+// context so next (and, for AddTransactionHandler, authorizePayer) can
+// consult it. A missing or invalid token is rejected with 401 before next
+// ever runs.
+func AuthMiddleware(connector Connector, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			respondWithJSON(w, http.StatusUnauthorized, map[string]string{"errors": "missing bearer token"})
+			return
+		}
+		id, err := connector.Authenticate(r.Context(), token)
+		if err != nil {
+			respondWithJSON(w, http.StatusUnauthorized, map[string]string{"errors": err.Error()})
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), identityContextKey{}, id)))
+	}
+}
+
+// bearerToken extracts the credential from a "Bearer <token>" Authorization
+// header.
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(auth, prefix))
+	if token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// authorizePayer reports whether ctx carries an Identity that's allowed to
+// act on behalf of payer. It returns true when ctx carries no Identity at
+// all, so AddTransactionHandler keeps working unauthenticated when
+// AuthMiddleware isn't wired in front of it.
+func authorizePayer(ctx context.Context, payer string) bool {
+	id, ok := identityFromContext(ctx)
+	if !ok {
+		return true
+	}
+	return id.allows(payer)
+}
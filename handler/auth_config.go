@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// AuthConfig is the JSON configuration for a Connector, loaded at startup
+// and dispatched to the matching constructor by NewConnector based on Type.
+type AuthConfig struct {
+	Type         string `json:"type"` // "github" or "oidc"
+	ID           string `json:"id"`
+	ClientID     string `json:"clientID,omitempty"`
+	ClientSecret string `json:"clientSecret,omitempty"`
+
+	// OrgPayerScopes is used by the "github" type: see GitHubConnector.
+	OrgPayerScopes map[string][]string `json:"orgPayerScopes,omitempty"`
+
+	// JWKSURL and Issuer are used by the "oidc" type: see OIDCConnector.
+	JWKSURL string `json:"jwksURL,omitempty"`
+	Issuer  string `json:"issuer,omitempty"`
+}
+
+// redacted returns a copy of cfg with ClientSecret cleared, for use
+// anywhere an AuthConfig is exposed outward (e.g. ConfigHandler's GET
+// response) rather than fed back into NewConnector.
+func (cfg AuthConfig) redacted() AuthConfig {
+	cfg.ClientSecret = ""
+	return cfg
+}
+
+// LoadConnectorConfig reads and parses an AuthConfig from the JSON file at
+// path.
+func LoadConnectorConfig(path string) (AuthConfig, error) {
+	var cfg AuthConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("reading auth config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parsing auth config: %w", err)
+	}
+	return cfg, nil
+}
+
+// NewConnector builds the Connector described by cfg.
+func NewConnector(cfg AuthConfig) (Connector, error) {
+	switch cfg.Type {
+	case "github":
+		return NewGitHubConnector(cfg.OrgPayerScopes), nil
+	case "oidc":
+		return NewOIDCConnector(cfg.JWKSURL, cfg.Issuer), nil
+	default:
+		return nil, fmt.Errorf("unknown connector type %q", cfg.Type)
+	}
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// GitHubConnector authenticates bearer tokens against the GitHub API and
+// maps the caller's organization memberships to payer scopes via
+// OrgPayerScopes - the same org-membership-to-scope pattern dex's github
+// connector uses for its own group claims.
+type GitHubConnector struct {
+	// APIBaseURL defaults to https://api.github.com; tests override it with
+	// an httptest.Server to stub the GitHub API.
+	APIBaseURL string
+	// OrgPayerScopes maps a GitHub organization login to the payer names
+	// its members are allowed to act on behalf of.
+	OrgPayerScopes map[string][]string
+
+	// httpClient defaults to http.DefaultClient; tests may override it.
+	httpClient *http.Client
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubOrg struct {
+	Login string `json:"login"`
+}
+
+// NewGitHubConnector constructs a GitHubConnector against the real GitHub
+// API using the given organization-to-payer-scope mapping.
+func NewGitHubConnector(orgPayerScopes map[string][]string) *GitHubConnector {
+	return &GitHubConnector{APIBaseURL: "https://api.github.com", OrgPayerScopes: orgPayerScopes}
+}
+
+// Authenticate calls GET /user and GET /user/orgs with token as a bearer
+// credential, then maps the caller's organizations through OrgPayerScopes to
+// build its allowed payer set.
+func (c *GitHubConnector) Authenticate(ctx context.Context, token string) (Identity, error) {
+	var user githubUser
+	if err := c.getJSON(ctx, token, "/user", &user); err != nil {
+		return Identity{}, fmt.Errorf("github: %w", err)
+	}
+	if user.Login == "" {
+		return Identity{}, fmt.Errorf("github: token did not resolve to a user")
+	}
+
+	var orgs []githubOrg
+	if err := c.getJSON(ctx, token, "/user/orgs", &orgs); err != nil {
+		return Identity{}, fmt.Errorf("github: %w", err)
+	}
+
+	var allowed []string
+	for _, org := range orgs {
+		allowed = append(allowed, c.OrgPayerScopes[org.Login]...)
+	}
+	return Identity{Subject: user.Login, AllowedPayers: allowed}, nil
+}
+
+func (c *GitHubConnector) client() *http.Client {
+	if c.httpClient != nil {
+		return c.httpClient
+	}
+	return http.DefaultClient
+}
+
+func (c *GitHubConnector) baseURL() string {
+	if c.APIBaseURL != "" {
+		return c.APIBaseURL
+	}
+	return "https://api.github.com"
+}
+
+func (c *GitHubConnector) getJSON(ctx context.Context, token, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL()+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status from %v: %v", path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
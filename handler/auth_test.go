@@ -0,0 +1,303 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGitHubConnectorAuthenticate(t *testing.T) {
+	cases := []struct {
+		name          string
+		token         string
+		login         string
+		orgs          []string
+		orgScopes     map[string][]string
+		wantErr       bool
+		wantAllowed   []string
+		wantAllowNone bool
+	}{
+		{
+			name:        "member of scoped org",
+			token:       "good-token",
+			login:       "octocat",
+			orgs:        []string{"fetch-rewards"},
+			orgScopes:   map[string][]string{"fetch-rewards": {"DANNON", "UNILEVER"}},
+			wantAllowed: []string{"DANNON", "UNILEVER"},
+		},
+		{
+			name:          "member of unscoped org",
+			token:         "good-token",
+			login:         "octocat",
+			orgs:          []string{"some-other-org"},
+			orgScopes:     map[string][]string{"fetch-rewards": {"DANNON"}},
+			wantAllowNone: true,
+		},
+		{
+			name:    "invalid token",
+			token:   "bad-token",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer good-token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				switch r.URL.Path {
+				case "/user":
+					json.NewEncoder(w).Encode(githubUser{Login: tc.login})
+				case "/user/orgs":
+					orgs := make([]githubOrg, 0, len(tc.orgs))
+					for _, o := range tc.orgs {
+						orgs = append(orgs, githubOrg{Login: o})
+					}
+					json.NewEncoder(w).Encode(orgs)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			connector := &GitHubConnector{APIBaseURL: server.URL, OrgPayerScopes: tc.orgScopes}
+			id, err := connector.Authenticate(context.Background(), tc.token)
+
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got identity %+v", id)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got, want := id.Subject, tc.login; got != want {
+				t.Errorf("got subject %v; want %v", got, want)
+			}
+			if tc.wantAllowNone {
+				if len(id.AllowedPayers) != 0 {
+					t.Errorf("expected no allowed payers, got %v", id.AllowedPayers)
+				}
+				return
+			}
+			if got, want := id.AllowedPayers, tc.wantAllowed; !equalStrings(got, want) {
+				t.Errorf("got allowed payers %v; want %v", got, want)
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// testRSAKeyPair generates an RSA key pair and the JWK form of its public
+// half, so tests can sign tokens and serve them from a stub JWKS endpoint
+// without any external dependency.
+func testRSAKeyPair(t *testing.T, kid string) (*rsa.PrivateKey, jwk) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	return key, jwk{
+		Kid: kid,
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(bigEndianBytes(key.PublicKey.E)),
+	}
+}
+
+func bigEndianBytes(n int) []byte {
+	if n == 0 {
+		return []byte{0}
+	}
+	var b []byte
+	for n > 0 {
+		b = append([]byte{byte(n & 0xff)}, b...)
+		n >>= 8
+	}
+	return b
+}
+
+// signTestJWT builds and RS256-signs a minimal JWT carrying claims, using
+// kid in its header so OIDCConnector can look up the matching JWK.
+func signTestJWT(t *testing.T, key *rsa.PrivateKey, kid string, claims oidcClaims) string {
+	t.Helper()
+	header := map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	hashed := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestOIDCConnectorAuthenticate(t *testing.T) {
+	key, publicJWK := testRSAKeyPair(t, "test-key")
+	otherKey, _ := testRSAKeyPair(t, "test-key")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jwksDoc{Keys: []jwk{publicJWK}})
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name        string
+		token       string
+		wantErr     bool
+		wantSubject string
+		wantAllowed []string
+	}{
+		{
+			name:        "valid token with payers claim",
+			token:       signTestJWT(t, key, "test-key", oidcClaims{Subject: "user-1", Payers: []string{"DANNON"}}),
+			wantSubject: "user-1",
+			wantAllowed: []string{"DANNON"},
+		},
+		{
+			name:    "signed by the wrong key",
+			token:   signTestJWT(t, otherKey, "test-key", oidcClaims{Subject: "user-1"}),
+			wantErr: true,
+		},
+		{
+			name:    "malformed token",
+			token:   "not-a-jwt",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			connector := NewOIDCConnector(server.URL, "")
+			id, err := connector.Authenticate(context.Background(), tc.token)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got identity %+v", id)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got, want := id.Subject, tc.wantSubject; got != want {
+				t.Errorf("got subject %v; want %v", got, want)
+			}
+			if !equalStrings(id.AllowedPayers, tc.wantAllowed) {
+				t.Errorf("got allowed payers %v; want %v", id.AllowedPayers, tc.wantAllowed)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareRejectsMissingAndInvalidTokens(t *testing.T) {
+	connector := &stubConnector{err: errUnauthorized}
+	wrapped := AuthMiddleware(connector, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next should not be called")
+	})
+
+	req := httptest.NewRequest("POST", "/transaction", nil)
+	rec := httptest.NewRecorder()
+	wrapped(rec, req)
+	if got, want := rec.Code, http.StatusUnauthorized; got != want {
+		t.Errorf("missing token: got status %v; want %v", got, want)
+	}
+
+	req = httptest.NewRequest("POST", "/transaction", nil)
+	req.Header.Set("Authorization", "Bearer bad-token")
+	rec = httptest.NewRecorder()
+	wrapped(rec, req)
+	if got, want := rec.Code, http.StatusUnauthorized; got != want {
+		t.Errorf("invalid token: got status %v; want %v", got, want)
+	}
+}
+
+func TestAddTransactionHandlerRejectsUnauthorizedPayer(t *testing.T) {
+	connector := &stubConnector{identity: Identity{Subject: "user-1", AllowedPayers: []string{"DANNON"}}}
+	wrapped := AuthMiddleware(connector, AddTransactionHandler)
+
+	req := httptest.NewRequest("POST", "/transaction", bytes.NewReader([]byte(`{ "payer": "UNILEVER", "points": 500, "timestamp": "2020-11-02T14:00:00Z" }`)))
+	req.Header.Set("Authorization", "Bearer good-token")
+	rec := httptest.NewRecorder()
+	wrapped(rec, req)
+
+	if got, want := rec.Code, http.StatusForbidden; got != want {
+		t.Errorf("got status %v; want %v", got, want)
+	}
+}
+
+func TestAddTransactionHandlerRejectsIdentityWithEmptyScope(t *testing.T) {
+	connector := &stubConnector{identity: Identity{Subject: "user-1"}}
+	wrapped := AuthMiddleware(connector, AddTransactionHandler)
+
+	req := httptest.NewRequest("POST", "/transaction", bytes.NewReader([]byte(`{ "payer": "DANNON", "points": 500, "timestamp": "2020-11-02T14:00:00Z" }`)))
+	req.Header.Set("Authorization", "Bearer good-token")
+	rec := httptest.NewRecorder()
+	wrapped(rec, req)
+
+	if got, want := rec.Code, http.StatusForbidden; got != want {
+		t.Errorf("an authenticated Identity with no AllowedPayers should be forbidden, not treated as unrestricted: got status %v; want %v", got, want)
+	}
+}
+
+func TestAddTransactionHandlerAllowsAuthorizedPayer(t *testing.T) {
+	connector := &stubConnector{identity: Identity{Subject: "user-1", AllowedPayers: []string{"DANNON"}}}
+	wrapped := AuthMiddleware(connector, AddTransactionHandler)
+
+	req := httptest.NewRequest("POST", "/transaction", bytes.NewReader([]byte(`{ "payer": "DANNON", "points": 500, "timestamp": "2020-11-02T14:00:00Z" }`)))
+	req.Header.Set("Authorization", "Bearer good-token")
+	rec := httptest.NewRecorder()
+	wrapped(rec, req)
+
+	if got, want := rec.Code, http.StatusCreated; got != want {
+		t.Errorf("got status %v; want %v", got, want)
+	}
+}
+
+var errUnauthorized = &stubError{"unauthorized"}
+
+type stubError struct{ msg string }
+
+func (e *stubError) Error() string { return e.msg }
+
+// stubConnector is a Connector test double: it returns err when set,
+// otherwise identity, regardless of the token presented.
+type stubConnector struct {
+	identity Identity
+	err      error
+}
+
+func (c *stubConnector) Authenticate(ctx context.Context, token string) (Identity, error) {
+	if c.err != nil {
+		return Identity{}, c.err
+	}
+	return c.identity, nil
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tboddyspargo/fetch/health"
+)
+
+func TestConfigGetSetNestedPath(t *testing.T) {
+	cfg := NewConfig(RuntimeConfig{Spend: SpendConfig{Strategy: "fifo"}})
+
+	value, err := cfg.MarshalJSONPath("/spend/strategy")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := string(value), `"fifo"`; got != want {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	if err := cfg.UnmarshalJSONPath("/spend/strategy", []byte(`"weighted"`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	value, err = cfg.MarshalJSONPath("/spend/strategy")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := string(value), `"weighted"`; got != want {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if got, want := cfg.cfg.Health.BusyThreshold, 0; got != want {
+		t.Errorf("unrelated field was touched: got %v; want %v", got, want)
+	}
+}
+
+func TestConfigDoLockedActionRejectsStaleFingerprint(t *testing.T) {
+	cfg := NewConfig(RuntimeConfig{LogLevel: "info"})
+	stale := cfg.Fingerprint()
+
+	if err := cfg.UnmarshalJSON([]byte(`{"logLevel":"debug"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := cfg.DoLockedAction(stale, func(c *Config) error {
+		t.Fatal("cb should not run against a stale fingerprint")
+		return nil
+	})
+	if err != ErrFingerprintMismatch {
+		t.Errorf("got error %v; want ErrFingerprintMismatch", err)
+	}
+
+	current := cfg.Fingerprint()
+	var ranWith string
+	if err := cfg.DoLockedAction(current, func(c *Config) error {
+		ranWith = c.cfg.LogLevel
+		return nil
+	}); err != nil {
+		t.Errorf("unexpected error with current fingerprint: %v", err)
+	}
+	if got, want := ranWith, "debug"; got != want {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestConfigUnmarshalYAML(t *testing.T) {
+	cfg := NewConfig(RuntimeConfig{})
+	yaml := "logLevel: debug\nspend.strategy: weighted\nhealth.busyThreshold: 20\n"
+
+	if err := cfg.UnmarshalYAML([]byte(yaml)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := cfg.cfg.LogLevel, "debug"; got != want {
+		t.Errorf("got logLevel %v; want %v", got, want)
+	}
+	if got, want := cfg.cfg.Spend.Strategy, "weighted"; got != want {
+		t.Errorf("got spend.strategy %v; want %v", got, want)
+	}
+	if got, want := cfg.cfg.Health.BusyThreshold, 20; got != want {
+		t.Errorf("got health.busyThreshold %v; want %v", got, want)
+	}
+}
+
+func TestConfigHandlerPatchReturns412OnFingerprintMismatch(t *testing.T) {
+	original := defaultConfig
+	defaultConfig = NewConfig(RuntimeConfig{LogLevel: "info"})
+	defer func() { defaultConfig = original }()
+
+	req := httptest.NewRequest("PATCH", "/config?path=/logLevel", strings.NewReader(`"debug"`))
+	req.Header.Set("If-Match", "not-the-real-fingerprint")
+	rec := httptest.NewRecorder()
+	ConfigHandler(rec, req)
+
+	if got, want := rec.Code, http.StatusPreconditionFailed; got != want {
+		t.Errorf("got status %v; want %v", got, want)
+	}
+}
+
+func TestConfigHandlerRejectsRequestsWithoutTheConfigToken(t *testing.T) {
+	SetConfigToken("operator-secret")
+	defer SetConfigToken("")
+
+	req := httptest.NewRequest("GET", "/config", nil)
+	rec := httptest.NewRecorder()
+	ConfigHandler(rec, req)
+	if got, want := rec.Code, http.StatusUnauthorized; got != want {
+		t.Errorf("no token: got status %v; want %v", got, want)
+	}
+
+	req = httptest.NewRequest("GET", "/config", nil)
+	req.Header.Set("Authorization", "Bearer wrong-token")
+	rec = httptest.NewRecorder()
+	ConfigHandler(rec, req)
+	if got, want := rec.Code, http.StatusUnauthorized; got != want {
+		t.Errorf("wrong token: got status %v; want %v", got, want)
+	}
+
+	req = httptest.NewRequest("GET", "/config", nil)
+	req.Header.Set("Authorization", "Bearer operator-secret")
+	rec = httptest.NewRecorder()
+	ConfigHandler(rec, req)
+	if got, want := rec.Code, http.StatusOK; got != want {
+		t.Errorf("correct token: got status %v; want %v", got, want)
+	}
+}
+
+func TestConfigHandlerGetRedactsClientSecret(t *testing.T) {
+	original := defaultConfig
+	defaultConfig = NewConfig(RuntimeConfig{Auth: AuthConfig{Type: "oidc", ClientSecret: "super-secret"}})
+	defer func() { defaultConfig = original }()
+
+	req := httptest.NewRequest("GET", "/config", nil)
+	rec := httptest.NewRecorder()
+	ConfigHandler(rec, req)
+
+	if got, want := rec.Code, http.StatusOK; got != want {
+		t.Fatalf("got status %v; want %v", got, want)
+	}
+	if strings.Contains(rec.Body.String(), "super-secret") {
+		t.Errorf("GET /config leaked ClientSecret: %v", rec.Body.String())
+	}
+
+	// clientSecret has an "omitempty" JSON tag, so redacting it to "" drops
+	// it from the marshaled object entirely rather than exposing an empty
+	// string at this path - still not a leak, just a 404 instead of "".
+	req = httptest.NewRequest("GET", "/config?path=/auth/clientSecret", nil)
+	rec = httptest.NewRecorder()
+	ConfigHandler(rec, req)
+	if got, want := rec.Code, http.StatusNotFound; got != want {
+		t.Errorf("GET /config?path=/auth/clientSecret: got status %v; want %v", got, want)
+	}
+	if strings.Contains(rec.Body.String(), "super-secret") {
+		t.Errorf("GET /config?path=/auth/clientSecret leaked the secret: %v", rec.Body.String())
+	}
+}
+
+func TestConfigHandlerPatchAppliesHealthThresholdsLive(t *testing.T) {
+	originalConfig := defaultConfig
+	defaultConfig = NewConfig(RuntimeConfig{Health: HealthThresholds{BusyThreshold: 10, ErrorThreshold: 5}})
+	defer func() { defaultConfig = originalConfig }()
+
+	originalTracker := health.Default
+	health.Default = health.New(10, 5)
+	defer func() { health.Default = originalTracker }()
+
+	fingerprint := defaultConfig.Fingerprint()
+	req := httptest.NewRequest("PATCH", "/config?path=/health/busyThreshold", strings.NewReader("2"))
+	req.Header.Set("If-Match", fingerprint)
+	rec := httptest.NewRecorder()
+	ConfigHandler(rec, req)
+
+	if got, want := rec.Code, http.StatusOK; got != want {
+		t.Fatalf("got status %v; want %v", got, want)
+	}
+
+	for i := 0; i < 3; i++ {
+		defer health.Default.BeginRequest()()
+	}
+	if got, want := health.Default.Report().Status, health.BusyStatus; got != want {
+		t.Errorf("patched busyThreshold wasn't applied to health.Default: got status %v; want BusyStatus", got)
+	}
+}
+
+func TestConfigHandlerGetAndPatchRoundTrip(t *testing.T) {
+	original := defaultConfig
+	defaultConfig = NewConfig(RuntimeConfig{LogLevel: "info"})
+	defer func() { defaultConfig = original }()
+
+	// The PATCH below applies the (zero-valued) Health fields of this
+	// test's Config live via applyRuntimeConfig - isolate health.Default
+	// so that doesn't clobber the real Tracker's thresholds for every test
+	// that runs after this one.
+	originalTracker := health.Default
+	health.Default = health.New(10, 5)
+	defer func() { health.Default = originalTracker }()
+
+	getReq := httptest.NewRequest("GET", "/config?path=/logLevel", nil)
+	getRec := httptest.NewRecorder()
+	ConfigHandler(getRec, getReq)
+
+	if got, want := getRec.Code, http.StatusOK; got != want {
+		t.Fatalf("GET: got status %v; want %v", got, want)
+	}
+	fingerprint := getRec.Header().Get("ETag")
+	if fingerprint == "" {
+		t.Fatal("GET: expected a non-empty ETag header")
+	}
+
+	patchReq := httptest.NewRequest("PATCH", "/config?path=/logLevel", strings.NewReader(`"debug"`))
+	patchReq.Header.Set("If-Match", fingerprint)
+	patchRec := httptest.NewRecorder()
+	ConfigHandler(patchRec, patchReq)
+
+	if got, want := patchRec.Code, http.StatusOK; got != want {
+		t.Fatalf("PATCH: got status %v; want %v", got, want)
+	}
+	if got, want := patchRec.Header().Get("ETag"), defaultConfig.Fingerprint(); got != want {
+		t.Errorf("PATCH: got ETag %v; want %v", got, want)
+	}
+
+	getReq = httptest.NewRequest("GET", "/config?path=/logLevel", nil)
+	getRec = httptest.NewRecorder()
+	ConfigHandler(getRec, getReq)
+	if got, want := getRec.Body.String(), `"debug"`; strings.TrimSpace(got) != want {
+		t.Errorf("got body %v; want %v", got, want)
+	}
+}
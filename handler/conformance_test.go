@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/tboddyspargo/fetch/points"
+)
+
+// conformanceVectorTransaction is one Transaction to seed a conformance
+// vector with, as it appears in a testdata/vectors/*.json file.
+type conformanceVectorTransaction struct {
+	Payer     string `json:"payer"`
+	Points    int32  `json:"points"`
+	Timestamp string `json:"timestamp"`
+}
+
+// conformanceVectorSpend is one SpendRequest to replay against
+// SpendPointsHandler, along with the outcome it's expected to produce.
+type conformanceVectorSpend struct {
+	Points            int32                 `json:"points"`
+	ExpectErrorStatus int                   `json:"expect_error_status"`
+	ExpectedResult    []points.PayerBalance `json:"expected_result"`
+}
+
+// conformanceVector describes a self-contained spend-ordering scenario: an
+// initial set of Transactions, a sequence of spends to replay against them,
+// and the PayerTotals that should remain once every spend has run.
+type conformanceVector struct {
+	Name                string                         `json:"name"`
+	Transactions        []conformanceVectorTransaction `json:"transactions"`
+	Spends              []conformanceVectorSpend       `json:"spends"`
+	ExpectedPayerTotals map[string]int32               `json:"expected_payer_totals"`
+}
+
+// loadConformanceVectors reads every testdata/vectors/*.json file relative
+// to this package's directory.
+func loadConformanceVectors(t *testing.T) []conformanceVector {
+	t.Helper()
+	paths, err := filepath.Glob("../testdata/vectors/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no conformance vectors found under testdata/vectors")
+	}
+
+	var vectors []conformanceVector
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v conformanceVector
+		if err := json.Unmarshal(data, &v); err != nil {
+			t.Fatalf("%v: %v", path, err)
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors
+}
+
+// TestConformance replays every testdata/vectors/*.json scenario through
+// AddTransactionHandler and SpendPointsHandler, exercising the same
+// FIFO-oldest-first, never-below-zero spend invariants points.TestConformance
+// checks, but end-to-end over HTTP.
+func TestConformance(t *testing.T) {
+	for _, v := range loadConformanceVectors(t) {
+		v := v
+		t.Run(v.Name, func(t *testing.T) {
+			points.ResetTransactions()
+
+			for _, vt := range v.Transactions {
+				body, _ := json.Marshal(vt)
+				req, err := http.NewRequest("POST", "/transaction", bytes.NewReader(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				recorder := httptest.NewRecorder()
+				http.HandlerFunc(AddTransactionHandler).ServeHTTP(recorder, req)
+				if recorder.Code != http.StatusCreated {
+					t.Fatalf("could not seed vector transaction %+v: status %v body %v", vt, recorder.Code, recorder.Body.String())
+				}
+			}
+
+			sawExpectedError := false
+			for i, spend := range v.Spends {
+				body, _ := json.Marshal(points.SpendRequest{Points: spend.Points})
+				req, err := http.NewRequest("POST", "/spend", bytes.NewReader(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				recorder := httptest.NewRecorder()
+				http.HandlerFunc(SpendPointsHandler).ServeHTTP(recorder, req)
+
+				if spend.ExpectErrorStatus != 0 {
+					sawExpectedError = true
+					if recorder.Code != spend.ExpectErrorStatus {
+						t.Errorf("spend %v: got status %v; want %v", i, recorder.Code, spend.ExpectErrorStatus)
+					}
+					continue
+				}
+				if recorder.Code != http.StatusOK {
+					t.Fatalf("spend %v: unexpected status %v: %v", i, recorder.Code, recorder.Body.String())
+				}
+				if spend.ExpectedResult != nil {
+					var got []points.PayerBalance
+					if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+						t.Fatal(err)
+					}
+					sort.Slice(got, func(i, j int) bool { return got[i].Payer < got[j].Payer })
+					want := append([]points.PayerBalance{}, spend.ExpectedResult...)
+					sort.Slice(want, func(i, j int) bool { return want[i].Payer < want[j].Payer })
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("spend %v: result mismatch: got %+v; want %+v", i, got, want)
+					}
+				}
+			}
+
+			// A spend rejected by the handler's own pre-check (expect_error_status)
+			// never reaches points.SpendPointsCtx, so the vector's
+			// expected_payer_totals - which describes the fully-replayed,
+			// nothing-rejected outcome - doesn't apply here.
+			if !sawExpectedError {
+				pt, err := points.GetPayerTotals()
+				if err != nil {
+					t.Fatal(err)
+				}
+				for payer, want := range v.ExpectedPayerTotals {
+					if got := pt[payer]; got != want {
+						t.Errorf("final payer total for %v: got %v; want %v", payer, got, want)
+					}
+				}
+			}
+		})
+	}
+}
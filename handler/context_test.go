@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tboddyspargo/fetch/points"
+)
+
+// TestSpendPointsHandlerRejectsCancelledRequestContext starts a spend
+// request whose context is already cancelled - standing in for a client
+// that disconnected before the server could respond - and asserts both that
+// the handler reports it (statusClientClosedRequest) and that no payer's
+// balance was mutated as a result.
+func TestSpendPointsHandlerRejectsCancelledRequestContext(t *testing.T) {
+	points.ResetTransactions()
+	tr, err := points.NewTransaction("DANNON", 1000, "2020-10-31T15:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := points.GetPayerTotals()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "/spend", bytes.NewReader([]byte(`{"points": 500}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	SpendPointsHandler(rec, req)
+
+	if got, want := rec.Code, statusClientClosedRequest; got != want {
+		t.Errorf("got status %v; want %v", got, want)
+	}
+
+	after, err := points.GetPayerTotals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := after["DANNON"], before["DANNON"]; got != want {
+		t.Errorf("payer balance was mutated despite a cancelled request context: got %v; want %v", got, want)
+	}
+}
+
+// TestAddTransactionHandlerRejectsCancelledRequestContext behaves like
+// TestSpendPointsHandlerRejectsCancelledRequestContext, but for the
+// transaction-creation route: a cancelled context should stop the
+// Transaction from being saved at all.
+func TestAddTransactionHandlerRejectsCancelledRequestContext(t *testing.T) {
+	points.ResetTransactions()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "/transaction", bytes.NewReader([]byte(`{ "payer": "DANNON", "points": 500, "timestamp": "2020-11-02T14:00:00Z" }`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	AddTransactionHandler(rec, req)
+
+	if got, want := rec.Code, statusClientClosedRequest; got != want {
+		t.Errorf("got status %v; want %v", got, want)
+	}
+
+	transactions, err := points.GetTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 0 {
+		t.Errorf("got %v transactions; want 0", len(transactions))
+	}
+}
+
+// TestSetHandlerTimeoutAppliesToSpendPointsHandler confirms a very small
+// handlerTimeout causes SpendPointsHandler to time out with a 504 rather
+// than hang, and restores the default afterward.
+func TestSetHandlerTimeoutAppliesToSpendPointsHandler(t *testing.T) {
+	original := handlerTimeout
+	SetHandlerTimeout(0)
+	defer SetHandlerTimeout(original)
+
+	points.ResetTransactions()
+	tr, err := points.NewTransaction("DANNON", 1000, "2020-10-31T15:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", "/spend", bytes.NewReader([]byte(`{"points": 500}`)))
+	rec := httptest.NewRecorder()
+	SpendPointsHandler(rec, req)
+
+	if got, want := rec.Code, http.StatusGatewayTimeout; got != want {
+		t.Errorf("got status %v; want %v", got, want)
+	}
+}
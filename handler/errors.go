@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/tboddyspargo/fetch/log"
+	"github.com/tboddyspargo/fetch/points"
+)
+
+// statusClientClosedRequest is nginx's de facto 499 status for "the client
+// closed the request before the server could respond". There's no standard
+// HTTP status for this, but it's a far more useful signal in logs and
+// metrics than lumping a client disconnect in with a genuine server error.
+const statusClientClosedRequest = 499
+
+// statusForCtxErr maps a context cancellation/deadline error to the HTTP
+// status a handler should respond with: statusClientClosedRequest for
+// context.Canceled, and 504 Gateway Timeout for context.DeadlineExceeded.
+// It reports false for any other error, so callers fall back to
+// respondWithPointsError.
+func statusForCtxErr(err error) (int, bool) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest, true
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, true
+	default:
+		return 0, false
+	}
+}
+
+// statusForKind maps a points.Kind to the HTTP status a handler should
+// respond with when it's the root cause of a points package error.
+func statusForKind(kind points.Kind) int {
+	switch kind {
+	case points.KindValidation:
+		return http.StatusBadRequest
+	case points.KindInsufficientFunds:
+		return http.StatusPaymentRequired
+	case points.KindConflict:
+		return http.StatusConflict
+	case points.KindStorage:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// logPointsError emits a structured log line for err, including its Op and
+// Kind when err is (or wraps) a *points.Error, so operators can filter log
+// lines on those fields instead of string-matching the message. Any other
+// error falls back to the plain log.Error.
+func logPointsError(err error) {
+	var perr *points.Error
+	if errors.As(err, &perr) {
+		log.Errorf("op=%v kind=%v payer=%v tx=%v error=%v", perr.Op, perr.Kind, perr.Payer, perr.TxID, perr.Err)
+		return
+	}
+	log.Error(err)
+}
+
+// respondWithPointsError logs err (structured, via logPointsError) and
+// writes a JSON error response whose status is derived from its Kind.
+func respondWithPointsError(w http.ResponseWriter, err error) {
+	logPointsError(err)
+	respondWithJSON(w, statusForKind(points.KindOf(err)), map[string]string{"errors": err.Error()})
+}
+
+// respondWithStoreError behaves like respondWithPointsError, but first
+// checks whether err is a context cancellation or deadline from a
+// context-aware points call (SaveCtx, SpendPointsCtx, GetPayerTotalsCtx),
+// responding with statusForCtxErr's status instead of treating it as an
+// ordinary points.Error.
+func respondWithStoreError(w http.ResponseWriter, err error) {
+	if status, ok := statusForCtxErr(err); ok {
+		log.Error(err)
+		respondWithJSON(w, status, map[string]string{"errors": err.Error()})
+		return
+	}
+	respondWithPointsError(w, err)
+}
@@ -1,17 +1,20 @@
 package handler
 
 import (
+	"context"
 	"net/http"
 
+	"github.com/tboddyspargo/fetch/health"
 	"github.com/tboddyspargo/fetch/log"
+	"github.com/tboddyspargo/fetch/points"
 )
 
 // These constants provide a set of private enum values representing web service status
 const (
-	idleStatus = iota
-	busyStatus
-	errorStatus
-	notRunningStatus
+	idleStatus       = health.IdleStatus
+	busyStatus       = health.BusyStatus
+	errorStatus      = health.ErrorStatus
+	notRunningStatus = health.NotRunningStatus
 )
 
 // HealthCheck is a struct for representing the health status of the web service.
@@ -19,12 +22,76 @@ type HealthCheck struct {
 	Status int `json:"status"`
 }
 
+// pointsChecker is the default readiness probe for the points package: it
+// confirms the active Store can still be read.
+type pointsChecker struct{}
+
+func (pointsChecker) Name() string { return "points" }
+
+func (pointsChecker) Check(ctx context.Context) error {
+	_, err := points.GetPayerTotals()
+	return err
+}
+
+func init() {
+	health.Default.SetTransactionCounter(func() int {
+		transactions, err := points.GetTransactions()
+		if err != nil {
+			return 0
+		}
+		return len(transactions)
+	})
+	health.Default.RegisterChecker(pointsChecker{})
+}
+
 // HealthCheckHandler provides an http response representing the health status of the web service.
+// It is kept for backwards compatibility with existing callers; HealthzHandler and ReadyzHandler
+// expose the fuller health.Report body.
 func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
-		respondWithJSON(w, http.StatusOK, HealthCheck{Status: idleStatus})
+		respondWithJSON(w, http.StatusOK, HealthCheck{Status: health.Default.Report().Status})
 	default:
 		log.Error("HealthCheckHandler only supports GET requests")
 	}
 }
+
+// HealthzHandler is a liveness probe: it reports NotRunningStatus only once the
+// process has been told to shut down, and OK otherwise, regardless of load or
+// recent errors.
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		report := health.Default.Report()
+		if report.Status == health.NotRunningStatus {
+			respondWithJSON(w, http.StatusServiceUnavailable, report)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, health.Report{Status: health.IdleStatus, UptimeSeconds: report.UptimeSeconds})
+	default:
+		methodErr := "HealthzHandler only supports GET requests"
+		log.Error(methodErr)
+		respondWithJSON(w, http.StatusMethodNotAllowed, struct{}{})
+	}
+}
+
+// ReadyzHandler is a readiness probe: it runs every registered
+// health.Checker (see pointsChecker above) and reports the service's real
+// busy/error status - including ErrorStatus if any Checker fails - so a load
+// balancer or orchestrator can stop routing traffic to an instance that's
+// overloaded, erroring, or missing a dependency.
+func ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		report := health.Default.Readiness(r.Context())
+		statusCode := http.StatusOK
+		if report.Status == health.ErrorStatus || report.Status == health.NotRunningStatus {
+			statusCode = http.StatusServiceUnavailable
+		}
+		respondWithJSON(w, statusCode, report)
+	default:
+		methodErr := "ReadyzHandler only supports GET requests"
+		log.Error(methodErr)
+		respondWithJSON(w, http.StatusMethodNotAllowed, struct{}{})
+	}
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// idempotencyTTL is how long a cached response remains eligible for replay
+// after the request that produced it.
+const idempotencyTTL = 24 * time.Hour
+
+// idempotencyCacheCapacity bounds how many keys the cache holds at once.
+// Once full, the oldest key is evicted to make room for a new one.
+const idempotencyCacheCapacity = 1000
+
+// cachedResponse is what an idempotency key maps to: enough to replay a
+// prior handler response byte-for-byte, plus a hash of the request body
+// that produced it so a later request reusing the same key can be checked
+// for a collision (the same key, a different body).
+type cachedResponse struct {
+	statusCode  int
+	body        []byte
+	expiresAt   time.Time
+	requestHash [sha256.Size]byte
+}
+
+// reservation tracks one in-flight handler execution that has claimed an
+// idempotency key, so a concurrent request bearing the same key can wait
+// for it to finish - via done - instead of racing it to execute the same
+// mutation twice.
+type reservation struct {
+	done chan struct{}
+}
+
+// idempotencyCache is a bounded, TTL-expiring map from Idempotency-Key header
+// values to the response they originally produced. It's intentionally a
+// simple map-plus-insertion-order-slice rather than a full LRU: entries are
+// evicted oldest-first regardless of access pattern, which is sufficient for
+// the "don't double-execute a client retry" use case it exists for.
+//
+// pending tracks keys whose handler is still running: reserve/release turn
+// "check the cache, then run the handler" into an atomic claim, closing the
+// gap where two requests bearing the same brand-new key could both miss the
+// cache and both execute the mutation.
+type idempotencyCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+	order   []string
+	pending map[string]*reservation
+}
+
+func newIdempotencyCache() *idempotencyCache {
+	return &idempotencyCache{entries: map[string]cachedResponse{}, pending: map[string]*reservation{}}
+}
+
+// Get returns the cached response for key, if any and not yet expired.
+func (c *idempotencyCache) Get(key string) (cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp, ok := c.entries[key]
+	if !ok {
+		return cachedResponse{}, false
+	}
+	if time.Now().After(resp.expiresAt) {
+		delete(c.entries, key)
+		return cachedResponse{}, false
+	}
+	return resp, true
+}
+
+// Put stores resp under key, evicting the oldest entry first if the cache is
+// already at capacity.
+func (c *idempotencyCache) Put(key string, resp cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[key]; !exists {
+		if len(c.order) >= idempotencyCacheCapacity {
+			oldest := c.order[0]
+			c.order = c.order[1:]
+			delete(c.entries, oldest)
+		}
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = resp
+}
+
+// reserve claims key for the calling goroutine if no one else already has,
+// returning owner == true in that case. Otherwise it returns the existing
+// reservation so the caller can wait on its done channel and retry once the
+// in-flight handler finishes.
+func (c *idempotencyCache) reserve(key string) (res *reservation, owner bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if existing, ok := c.pending[key]; ok {
+		return existing, false
+	}
+	res = &reservation{done: make(chan struct{})}
+	c.pending[key] = res
+	return res, true
+}
+
+// release clears key's reservation and wakes any request waiting on it.
+// Callers must call this exactly once for every reserve() that returned
+// owner == true, whether or not they went on to Put a cached response.
+func (c *idempotencyCache) release(key string) {
+	c.mu.Lock()
+	res, ok := c.pending[key]
+	if ok {
+		delete(c.pending, key)
+	}
+	c.mu.Unlock()
+	if ok {
+		close(res.done)
+	}
+}
+
+// idempotency is the process-wide cache consulted by handlers that accept an
+// Idempotency-Key header.
+var idempotency = newIdempotencyCache()
+
+// requestIdempotencyKey extracts the client-supplied idempotency key from a
+// request: the Idempotency-Key header takes precedence, falling back to an
+// "idempotency_key" field in the JSON request body if the header is absent.
+func requestIdempotencyKey(r *http.Request, body []byte) string {
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		return key
+	}
+	var fallback struct {
+		IdempotencyKey string `json:"idempotency_key"`
+	}
+	if err := json.Unmarshal(body, &fallback); err == nil {
+		return fallback.IdempotencyKey
+	}
+	return ""
+}
+
+// checkIdempotency looks up key in the cache and, if found, writes a
+// response without the caller having to re-execute its handler logic: the
+// original response is replayed if body matches what produced it, or a 409
+// Conflict is written if body doesn't (the same key reused for a different
+// request). If key isn't cached yet, it reserves key for the caller instead
+// of simply reporting a miss, so a concurrent duplicate request blocks on
+// that reservation rather than racing to execute the same mutation - the
+// reservation is released, waking any waiter, by a deferred call to
+// idempotency.release once the caller's handler finishes. It reports
+// whether it already wrote a response (handled), in which case callers
+// should return immediately, and whether the caller now owns the
+// reservation (owner), in which case callers must arrange to release it.
+func checkIdempotency(w http.ResponseWriter, key string, body []byte) (owner bool, handled bool) {
+	if key == "" {
+		return false, false
+	}
+	for {
+		if cached, ok := idempotency.Get(key); ok {
+			return false, replayIdempotentResponse(w, cached, body)
+		}
+		res, isOwner := idempotency.reserve(key)
+		if isOwner {
+			return true, false
+		}
+		<-res.done
+	}
+}
+
+// replayIdempotentResponse writes cached as the response, or a 409 Conflict
+// if body doesn't hash to the same request that originally produced it.
+func replayIdempotentResponse(w http.ResponseWriter, cached cachedResponse, body []byte) bool {
+	if cached.requestHash != sha256.Sum256(body) {
+		respondWithJSON(w, http.StatusConflict, map[string]string{"errors": "Idempotency-Key was already used with a different request body"})
+		return true
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(cached.statusCode)
+	w.Write(cached.body)
+	return true
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/tboddyspargo/fetch/points"
+)
+
+func TestSpendPointsHandlerIdempotencyKeyPreventsDoubleSpend(t *testing.T) {
+	points.ResetTransactions()
+
+	tr, _ := points.NewTransaction("DANNON", 1000, "2020-11-02T14:00:00Z")
+	tr.Save()
+
+	spendBytes, _ := json.Marshal(points.SpendRequest{Points: 300})
+
+	do := func() *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/spend", bytes.NewReader(spendBytes))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Idempotency-Key", "test-key-1")
+		recorder := httptest.NewRecorder()
+		http.HandlerFunc(SpendPointsHandler).ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	first := do()
+	second := do()
+
+	if got, want := first.Body.String(), second.Body.String(); got != want {
+		t.Errorf("replayed response body differs from original: got %v expected %v", got, want)
+	}
+
+	pt, _ := points.GetPayerTotals()
+	if got, want := pt["DANNON"], int32(700); got != want {
+		t.Errorf("duplicate request with the same Idempotency-Key double-spent: got %v expected %v", got, want)
+	}
+}
+
+// TestSpendPointsHandlerIdempotencyKeyPreventsConcurrentDoubleSpend sends
+// two requests bearing the same brand-new Idempotency-Key at the same time
+// - the scenario a client retrying after a network error actually produces
+// - and asserts only one of them executed the spend: the key being new to
+// the cache must not let both requests race past checkIdempotency and both
+// call SpendPointsCtx.
+func TestSpendPointsHandlerIdempotencyKeyPreventsConcurrentDoubleSpend(t *testing.T) {
+	points.ResetTransactions()
+
+	tr, _ := points.NewTransaction("DANNON", 1000, "2020-11-02T14:00:00Z")
+	tr.Save()
+
+	spendBytes, _ := json.Marshal(points.SpendRequest{Points: 300})
+
+	const concurrency = 8
+	var ready, start, done sync.WaitGroup
+	ready.Add(concurrency)
+	start.Add(1)
+	done.Add(concurrency)
+	recorders := make([]*httptest.ResponseRecorder, concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func(i int) {
+			defer done.Done()
+			req, err := http.NewRequest("POST", "/spend", bytes.NewReader(spendBytes))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			req.Header.Set("Idempotency-Key", "concurrent-key")
+			recorders[i] = httptest.NewRecorder()
+			ready.Done()
+			start.Wait()
+			http.HandlerFunc(SpendPointsHandler).ServeHTTP(recorders[i], req)
+		}(i)
+	}
+	ready.Wait()
+	start.Done()
+	done.Wait()
+
+	for i, rec := range recorders {
+		if got, want := rec.Body.String(), recorders[0].Body.String(); got != want {
+			t.Errorf("request %v got a different replayed body than request 0: got %v; want %v", i, got, want)
+		}
+	}
+
+	pt, _ := points.GetPayerTotals()
+	if got, want := pt["DANNON"], int32(700); got != want {
+		t.Errorf("concurrent requests sharing a new Idempotency-Key double-spent: got %v expected %v", got, want)
+	}
+}
+
+func TestSpendPointsHandlerIdempotencyKeyConflict(t *testing.T) {
+	points.ResetTransactions()
+
+	tr, _ := points.NewTransaction("DANNON", 1000, "2020-11-02T14:00:00Z")
+	tr.Save()
+
+	spend := func(amount int32) *httptest.ResponseRecorder {
+		spendBytes, _ := json.Marshal(points.SpendRequest{Points: amount})
+		req, err := http.NewRequest("POST", "/spend", bytes.NewReader(spendBytes))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Idempotency-Key", "test-key-conflict")
+		recorder := httptest.NewRecorder()
+		http.HandlerFunc(SpendPointsHandler).ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	first := spend(300)
+	if got, want := first.Code, http.StatusOK; got != want {
+		t.Fatalf("first request returned unexpected status code: got %v; want %v", got, want)
+	}
+
+	second := spend(400)
+	if got, want := second.Code, http.StatusConflict; got != want {
+		t.Errorf("reusing the same Idempotency-Key with a different body didn't return 409: got %v; want %v", got, want)
+	}
+
+	pt, _ := points.GetPayerTotals()
+	if got, want := pt["DANNON"], int32(700); got != want {
+		t.Errorf("the conflicting second request should not have spent any points: got %v expected %v", got, want)
+	}
+}
+
+func TestAddTransactionHandlerIdempotencyKeyFromRequestBody(t *testing.T) {
+	points.ResetTransactions()
+
+	body := []byte(`{"payer":"DANNON","points":500,"timestamp":"2020-11-02T14:00:00Z","idempotency_key":"body-key-1"}`)
+
+	do := func() *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/transaction", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := httptest.NewRecorder()
+		http.HandlerFunc(AddTransactionHandler).ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	first := do()
+	second := do()
+
+	if got, want := first.Body.String(), second.Body.String(); got != want {
+		t.Errorf("replayed response body differs from original: got %v expected %v", got, want)
+	}
+
+	transactions, _ := points.GetTransactions()
+	if got, want := len(transactions), 1; got != want {
+		t.Errorf("a repeated idempotency_key from the request body should not have saved a second transaction: got %v expected %v", got, want)
+	}
+}
+
+func TestSimulateSpendHandlerLeavesPayerTotalsUnchanged(t *testing.T) {
+	points.ResetTransactions()
+
+	tr, _ := points.NewTransaction("DANNON", 1000, "2020-11-02T14:00:00Z")
+	tr.Save()
+
+	spendBytes, _ := json.Marshal(points.SpendRequest{Points: 300})
+	req, err := http.NewRequest("POST", "/spend/simulate", bytes.NewReader(spendBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(SimulateSpendHandler).ServeHTTP(recorder, req)
+
+	if got, want := recorder.Code, http.StatusOK; got != want {
+		t.Fatalf("handler returned unexpected status code: got %v; want %v", got, want)
+	}
+
+	pt, _ := points.GetPayerTotals()
+	if got, want := pt["DANNON"], int32(1000); got != want {
+		t.Errorf("simulate endpoint mutated payer totals: got %v expected %v", got, want)
+	}
+}
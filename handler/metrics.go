@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tboddyspargo/fetch/log"
+	"github.com/tboddyspargo/fetch/metrics"
+	"github.com/tboddyspargo/fetch/points"
+)
+
+// These are the metrics published at /metrics. transactionsTotal,
+// spendRequestsTotal, and spendPointsTotal describe activity over time, so
+// they're maintained incrementally by AddTransactionHandler and
+// SpendPointsHandler as requests complete. The point balance gauge has no
+// such state of its own - points.GetPayerTotals() is already the live
+// projection the points package maintains, so MetricsHandler just reads it
+// fresh on every scrape.
+var (
+	transactionsTotal  = metrics.NewCounter()
+	spendRequestsTotal = metrics.NewCounter()
+	spendPointsTotal   = metrics.NewCounter()
+	requestDuration    = metrics.NewHistogram()
+)
+
+// statusRecorder wraps an http.ResponseWriter to capture the status code a
+// handler writes, so Instrumented can label
+// fetch_http_request_duration_seconds by outcome without requiring every
+// handler to report its own status.
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Instrumented wraps next so that every call is timed and its response
+// status recorded against fetch_http_request_duration_seconds, labeled by
+// name and the status code next produced. fetch.go uses it to wrap the
+// routes it registers; tests can use it directly to exercise the same
+// metric that a real deployment would record.
+func Instrumented(name string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		start := time.Now()
+		next(rec, r)
+		requestDuration.Observe(name, strconv.Itoa(rec.statusCode), time.Since(start).Seconds())
+	}
+}
+
+// MetricsHandler provides a Prometheus text-exposition-format (v0.0.4)
+// response summarizing the service's transaction, spend, and request-latency
+// activity, for scraping by a Prometheus-compatible collector.
+func MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		transactionsTotal.WriteTo(w, "fetch_transactions_total", "Total number of transactions recorded, by payer.", "payer")
+
+		pointsSum := metrics.NewGauge()
+		pt, _ := points.GetPayerTotals()
+		for payer, total := range pt {
+			pointsSum.Set(payer, float64(total))
+		}
+		pointsSum.WriteTo(w, "fetch_transaction_points_sum", "Current point balance for each payer.", "payer")
+
+		spendRequestsTotal.WriteTo(w, "fetch_spend_requests_total", "Total number of spend requests, by result.", "result")
+		spendPointsTotal.WriteTo(w, "fetch_spend_points_total", "Total number of points spent across all payers.", "")
+		requestDuration.WriteTo(w, "fetch_http_request_duration_seconds", "HTTP request latency in seconds, by handler and response code.")
+	default:
+		log.Error("MetricsHandler only supports GET requests")
+	}
+}
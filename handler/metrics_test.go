@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tboddyspargo/fetch/points"
+)
+
+// metricValue extracts the numeric value of the first line in body matching
+// prefix (e.g. `fetch_spend_requests_total{result="ok"}`), or 0 if absent.
+// transactionsTotal and friends are package-level counters shared by every
+// test in this package, so assertions here compare deltas across a scrape
+// rather than the absolute value, which would be order-dependent.
+func metricValue(t *testing.T, body, prefix string) float64 {
+	t.Helper()
+	re := regexp.MustCompile(regexp.QuoteMeta(prefix) + ` ([0-9.e+-]+)`)
+	m := re.FindStringSubmatch(body)
+	if m == nil {
+		return 0
+	}
+	v, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		t.Fatalf("unable to parse metric value %q: %v", m[1], err)
+	}
+	return v
+}
+
+func scrapeMetrics(t *testing.T) string {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rec := httptest.NewRecorder()
+	MetricsHandler(rec, req)
+	return rec.Body.String()
+}
+
+func TestMetricsHandlerReportsActivity(t *testing.T) {
+	points.ResetTransactions()
+	before := scrapeMetrics(t)
+
+	addReq := httptest.NewRequest("POST", "/transaction", strings.NewReader(`{ "payer": "DANNON", "points": 500, "timestamp": "2020-11-02T14:00:00Z" }`))
+	addRec := httptest.NewRecorder()
+	Instrumented("AddTransactionHandler", AddTransactionHandler)(addRec, addReq)
+	if got, want := addRec.Code, http.StatusCreated; got != want {
+		t.Fatalf("AddTransactionHandler: got status %v; want %v", got, want)
+	}
+
+	spendReq := httptest.NewRequest("POST", "/spend", strings.NewReader(`{ "points": 100 }`))
+	spendRec := httptest.NewRecorder()
+	Instrumented("SpendPointsHandler", SpendPointsHandler)(spendRec, spendReq)
+	if got, want := spendRec.Code, http.StatusOK; got != want {
+		t.Fatalf("SpendPointsHandler: got status %v; want %v", got, want)
+	}
+
+	after := scrapeMetrics(t)
+
+	if got, want := metricValue(t, after, `fetch_transactions_total{payer="DANNON"}`)-metricValue(t, before, `fetch_transactions_total{payer="DANNON"}`), 1.0; got != want {
+		t.Errorf("fetch_transactions_total{payer=\"DANNON\"} delta: got %v; want %v", got, want)
+	}
+	if got, want := metricValue(t, after, `fetch_transaction_points_sum{payer="DANNON"}`), 400.0; got != want {
+		t.Errorf("fetch_transaction_points_sum{payer=\"DANNON\"}: got %v; want %v", got, want)
+	}
+	if got, want := metricValue(t, after, `fetch_spend_requests_total{result="ok"}`)-metricValue(t, before, `fetch_spend_requests_total{result="ok"}`), 1.0; got != want {
+		t.Errorf("fetch_spend_requests_total{result=\"ok\"} delta: got %v; want %v", got, want)
+	}
+	if got, want := metricValue(t, after, "fetch_spend_points_total")-metricValue(t, before, "fetch_spend_points_total"), 100.0; got != want {
+		t.Errorf("fetch_spend_points_total delta: got %v; want %v", got, want)
+	}
+	if got, want := metricValue(t, after, `fetch_http_request_duration_seconds_count{handler="AddTransactionHandler",code="201"}`)-metricValue(t, before, `fetch_http_request_duration_seconds_count{handler="AddTransactionHandler",code="201"}`), 1.0; got != want {
+		t.Errorf("fetch_http_request_duration_seconds_count{handler=\"AddTransactionHandler\",code=\"201\"} delta: got %v; want %v", got, want)
+	}
+	if got, want := metricValue(t, after, `fetch_http_request_duration_seconds_count{handler="SpendPointsHandler",code="200"}`)-metricValue(t, before, `fetch_http_request_duration_seconds_count{handler="SpendPointsHandler",code="200"}`), 1.0; got != want {
+		t.Errorf("fetch_http_request_duration_seconds_count{handler=\"SpendPointsHandler\",code=\"200\"} delta: got %v; want %v", got, want)
+	}
+}
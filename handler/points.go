@@ -2,12 +2,15 @@ package handler
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"sort"
+	"time"
 
+	"github.com/tboddyspargo/fetch/health"
 	"github.com/tboddyspargo/fetch/log"
 	"github.com/tboddyspargo/fetch/points"
 )
@@ -27,30 +30,80 @@ func respondWithJSON(w http.ResponseWriter, statusCode int, content interface{})
 	w.Write(response)
 }
 
+// respondWithJSONIdempotent behaves like respondWithJSON, but additionally
+// caches the response under key (when non-empty), alongside a hash of
+// requestBody, so that a later request bearing the same Idempotency-Key can
+// be replayed via checkIdempotency instead of re-executing the mutation
+// that produced it.
+func respondWithJSONIdempotent(w http.ResponseWriter, key string, requestBody []byte, statusCode int, content interface{}) {
+	log.Info(content)
+	response, err := json.Marshal(content)
+	if err != nil {
+		log.Errorf("unable to convert content to json: content %v; error %v", content, err)
+		respondWithJSON(w, http.StatusInternalServerError, map[string]string{"errors": err.Error()})
+		return
+	}
+	if key != "" {
+		idempotency.Put(key, cachedResponse{
+			statusCode:  statusCode,
+			body:        response,
+			expiresAt:   time.Now().Add(idempotencyTTL),
+			requestHash: sha256.Sum256(requestBody),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(response)
+}
+
 // AddTransactionHandler provides http action for creating new Transaction records.
 // The body of the request is expected to contain the relevant fields for a Transaction object.
 // All Transactions created by this route are expected to represent points coming from a payer, not initiated by a user.
+// It respects a client-supplied idempotency key - either an Idempotency-Key request header or an
+// "idempotency_key" field in the request body - replaying the original response instead of saving
+// a second Transaction if the key has already been seen within the cache's TTL. Reusing a key with
+// a different request body is rejected with a 409 Conflict rather than silently replayed.
 func AddTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	defer health.Default.BeginRequest()()
 	switch r.Method {
 	case "POST":
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
 		defer r.Body.Close()
-		var t points.Transaction
 		body, _ := io.ReadAll(r.Body)
 		log.Infof("AddTransactionHandler(): received request: %v", string(body))
 
+		idemKey := requestIdempotencyKey(r, body)
+		owner, handled := checkIdempotency(w, idemKey, body)
+		if handled {
+			return
+		}
+		if owner {
+			defer idempotency.release(idemKey)
+		}
+
+		var t points.Transaction
 		// Populate the transaction object (t) from the body of the request.
 		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&t); err != nil {
 			log.Error(err)
+			health.Default.RecordError(err)
 			respondWithJSON(w, http.StatusBadRequest, map[string]string{"errors": err.Error()})
 			return
 		}
 
-		if err := t.Save(); err != nil {
-			log.Error(err)
-			respondWithJSON(w, http.StatusBadRequest, map[string]string{"errors": err.Error()})
+		if !authorizePayer(ctx, t.Payer) {
+			respondWithJSON(w, http.StatusForbidden, map[string]string{"errors": fmt.Sprintf("not authorized for payer %v", t.Payer)})
 			return
 		}
-		respondWithJSON(w, http.StatusCreated, t)
+
+		if err := t.SaveCtx(ctx); err != nil {
+			health.Default.RecordError(err)
+			respondWithStoreError(w, err)
+			return
+		}
+		transactionsTotal.Inc(t.Payer)
+		respondWithJSONIdempotent(w, idemKey, body, http.StatusCreated, t)
 	default:
 		methodErr := "AddTransactionHandler only supports POST requests"
 		log.Error(methodErr)
@@ -62,7 +115,14 @@ func AddTransactionHandler(w http.ResponseWriter, r *http.Request) {
 func PayerPointsHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
-		pt, _ := points.GetPayerTotals()
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		pt, err := points.GetPayerTotalsCtx(ctx)
+		if err != nil {
+			respondWithStoreError(w, err)
+			return
+		}
 		respondWithJSON(w, http.StatusOK, pt.ToPayerBalances())
 	default:
 		methodErr := "PayerPointsHandler only supports GET requests"
@@ -75,51 +135,102 @@ func PayerPointsHandler(w http.ResponseWriter, r *http.Request) {
 // The body of the request is expected to contain a "points" attribute indicating how many points the user would like to spend.
 // Points will be spent in order of oldest to most recent and points will not be spent if doing so would bring the balance associated with a particular payer below zero.
 // The response will be in the form of a JSON array containing objects representing how many points were used from each payer to satisfy the request.
+// It respects a client-supplied idempotency key - either an Idempotency-Key request header or an
+// "idempotency_key" field in the request body - replaying the original response instead of spending
+// twice if the key has already been seen within the cache's TTL. Reusing a key with a different
+// request body is rejected with a 409 Conflict rather than silently replayed.
 func SpendPointsHandler(w http.ResponseWriter, r *http.Request) {
+	defer health.Default.BeginRequest()()
+	defer health.Default.BeginSpend()()
 	switch r.Method {
 	case "POST":
+		ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
+		defer cancel()
+
+		defer r.Body.Close()
+		body, _ := io.ReadAll(r.Body)
+
+		idemKey := requestIdempotencyKey(r, body)
+		owner, handled := checkIdempotency(w, idemKey, body)
+		if handled {
+			return
+		}
+		if owner {
+			defer idempotency.release(idemKey)
+		}
+
 		var desiredSpend points.SpendRequest
-		err := json.NewDecoder(r.Body).Decode(&desiredSpend)
-		if err != nil {
+		if err := json.Unmarshal(body, &desiredSpend); err != nil {
 			log.Errorf("unable to parse json: %v", err)
+			health.Default.RecordError(err)
+			spendRequestsTotal.Inc("error")
 			respondWithJSON(w, http.StatusBadRequest, map[string]string{"errors": err.Error()})
 			return
 		}
 
-		totalAvailable, _ := points.TotalAvailable()
+		payerTotals, err := points.GetPayerTotalsCtx(ctx)
+		if err != nil {
+			health.Default.RecordError(err)
+			spendRequestsTotal.Inc("error")
+			respondWithStoreError(w, err)
+			return
+		}
+		var totalAvailable int32
+		for _, total := range payerTotals {
+			totalAvailable += total
+		}
 		if totalAvailable < desiredSpend.Points {
-			spendErr := fmt.Errorf("insufficient points. requested: %v; available: %v", desiredSpend.Points, totalAvailable)
-			log.Error(spendErr)
-			respondWithJSON(w, http.StatusBadRequest, map[string]string{"errors": spendErr.Error()})
+			spendErr := points.E("handler.SpendPointsHandler", points.KindInsufficientFunds, fmt.Errorf("insufficient points. requested: %v; available: %v", desiredSpend.Points, totalAvailable))
+			health.Default.RecordError(spendErr)
+			spendRequestsTotal.Inc("insufficient")
+			respondWithPointsError(w, spendErr)
 			return
 		}
 
-		// Sort the transactions in order of oldest to newest.
-		transactions, _ := points.GetTransactions()
-		sort.Sort(points.ByTimestamp(transactions))
-
-		// Keep track of how many points are spent from each payer to satisfy this request.
-		spentPayerPoints := points.PayerTotals{}
+		spentPayerPoints, spendErr := points.SpendPointsCtx(ctx, desiredSpend.Points)
+		if spendErr != nil {
+			health.Default.RecordError(spendErr)
+			spendRequestsTotal.Inc("error")
+			respondWithStoreError(w, spendErr)
+			return
+		}
+		result := spentPayerPoints.ToPayerBalances()
+		spendRequestsTotal.Inc("ok")
+		for _, balance := range result {
+			spendPointsTotal.Add("", float64(-balance.Points))
+		}
+		respondWithJSONIdempotent(w, idemKey, body, http.StatusOK, result)
+	default:
+		methodErr := "SpendPointsHandler only supports POST requests"
+		log.Error(methodErr)
+		respondWithJSON(w, http.StatusMethodNotAllowed, struct{}{})
+	}
+}
 
-		var remainingToSpend int32 = desiredSpend.Points
-		for _, t := range transactions {
-			// If all requested points have been spent, we're done
-			if remainingToSpend <= 0 {
-				break
-			}
+// SimulateSpendHandler provides an http action for previewing how a spend
+// would be split across payers without actually spending any points. The
+// request and response shapes match SpendPointsHandler, and GetPayerTotals()
+// is left unchanged by this route. Unlike SpendPointsHandler, it has no
+// insufficient-points check: a spend larger than the total available gets a
+// 200 showing whatever could be spent. It also ignores idempotency keys.
+func SimulateSpendHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "POST":
+		var desiredSpend points.SpendRequest
+		if err := json.NewDecoder(r.Body).Decode(&desiredSpend); err != nil {
+			log.Errorf("unable to parse json: %v", err)
+			respondWithJSON(w, http.StatusBadRequest, map[string]string{"errors": err.Error()})
+			return
+		}
 
-			// Attempt to spend the points from this transaction.
-			currentSpent, spendErr := t.SpendPoints(remainingToSpend)
-			if spendErr != nil {
-				continue
-			}
-			spentPayerPoints[t.Payer] -= currentSpent
-			remainingToSpend -= currentSpent
+		result, err := points.SimulateSpend(desiredSpend.Points)
+		if err != nil {
+			respondWithPointsError(w, err)
+			return
 		}
-		result := spentPayerPoints.ToPayerBalances()
 		respondWithJSON(w, http.StatusOK, result)
 	default:
-		methodErr := "SpendPointsHandler only supports POST requests"
+		methodErr := "SimulateSpendHandler only supports POST requests"
 		log.Error(methodErr)
 		respondWithJSON(w, http.StatusMethodNotAllowed, struct{}{})
 	}
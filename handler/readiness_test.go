@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tboddyspargo/fetch/health"
+)
+
+// failingChecker is a health.Checker that always fails, for exercising
+// ReadyzHandler's error path.
+type failingChecker struct{}
+
+func (failingChecker) Name() string { return "failing" }
+
+func (failingChecker) Check(ctx context.Context) error {
+	return errors.New("dependency unreachable")
+}
+
+func TestReadyzHandlerReportsFailingChecker(t *testing.T) {
+	original := health.Default
+	defer func() { health.Default = original }()
+
+	health.Default = health.New(10, 5)
+	health.Default.RegisterChecker(failingChecker{})
+
+	req := httptest.NewRequest("GET", "/healthz/ready", nil)
+	rec := httptest.NewRecorder()
+	ReadyzHandler(rec, req)
+
+	if got, want := rec.Code, http.StatusServiceUnavailable; got != want {
+		t.Errorf("got status %v; want %v", got, want)
+	}
+
+	var report health.Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("could not parse JSON: %v", err)
+	}
+	if got, want := report.Status, health.ErrorStatus; got != want {
+		t.Errorf("got status field %v; want %v", got, want)
+	}
+	if len(report.Checks) != 1 {
+		t.Fatalf("got %v checks; want 1", len(report.Checks))
+	}
+	check := report.Checks[0]
+	if got, want := check.Name, "failing"; got != want {
+		t.Errorf("got check name %v; want %v", got, want)
+	}
+	if check.OK {
+		t.Errorf("expected check to report OK=false")
+	}
+	if check.Error == "" {
+		t.Errorf("expected check to report its error")
+	}
+}
+
+func TestReadyzHandlerPassesWithDefaultCheckers(t *testing.T) {
+	// Use an isolated Tracker (rather than health.Default) so this test
+	// doesn't depend on the error/request counts left behind by every
+	// other test that's shared the global Default by the time this runs.
+	original := health.Default
+	defer func() { health.Default = original }()
+
+	health.Default = health.New(10, 5)
+	health.Default.RegisterChecker(pointsChecker{})
+
+	req := httptest.NewRequest("GET", "/healthz/ready", nil)
+	rec := httptest.NewRecorder()
+	ReadyzHandler(rec, req)
+
+	if got, want := rec.Code, http.StatusOK; got != want {
+		t.Errorf("got status %v; want %v", got, want)
+	}
+
+	var report health.Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("could not parse JSON: %v", err)
+	}
+	found := false
+	for _, c := range report.Checks {
+		if c.Name == "points" {
+			found = true
+			if !c.OK {
+				t.Errorf("expected points checker to pass, got error %q", c.Error)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected a %q check in the report, got %v", "points", report.Checks)
+	}
+}
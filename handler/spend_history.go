@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/tboddyspargo/fetch/log"
+	"github.com/tboddyspargo/fetch/points"
+)
+
+// SpendHistoryHandler provides an http response listing every Posting in
+// the spend journal, oldest first, optionally filtered by the "payer" query
+// parameter.
+func SpendHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		postings, err := points.GetSpendJournal()
+		if err != nil {
+			log.Error(err)
+			respondWithJSON(w, http.StatusInternalServerError, map[string]string{"errors": err.Error()})
+			return
+		}
+
+		payer := r.URL.Query().Get("payer")
+		filtered := make([]points.Posting, 0, len(postings))
+		for _, p := range postings {
+			if payer != "" && p.Payer != payer {
+				continue
+			}
+			filtered = append(filtered, p)
+		}
+		respondWithJSON(w, http.StatusOK, filtered)
+	default:
+		methodErr := "SpendHistoryHandler only supports GET requests"
+		log.Error(methodErr)
+		respondWithJSON(w, http.StatusMethodNotAllowed, struct{}{})
+	}
+}
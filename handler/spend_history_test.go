@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tboddyspargo/fetch/points"
+)
+
+func TestSpendHistoryHandlerFiltersByPayer(t *testing.T) {
+	points.ResetTransactions()
+
+	dannon, _ := points.NewTransaction("DANNON", 1000, "2020-10-31T15:00:00Z")
+	dannon.Save()
+	unilever, _ := points.NewTransaction("UNILEVER", 1000, "2020-10-31T15:00:00Z")
+	unilever.Save()
+
+	dannon.SpendPoints(300)
+	unilever.SpendPoints(200)
+
+	req, err := http.NewRequest("GET", "/spend-history?payer=DANNON", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(SpendHistoryHandler).ServeHTTP(recorder, req)
+
+	if got, want := recorder.Code, http.StatusOK; got != want {
+		t.Fatalf("handler returned unexpected status code: got %v; want %v", got, want)
+	}
+
+	var postings []points.Posting
+	if err := json.Unmarshal(recorder.Body.Bytes(), &postings); err != nil {
+		t.Fatal(err)
+	}
+	if len(postings) != 1 {
+		t.Fatalf("expected 1 posting for DANNON, got %v", len(postings))
+	}
+	if got, want := postings[0].Payer, "DANNON"; got != want {
+		t.Errorf("posting has wrong payer: got %v expected %v", got, want)
+	}
+	if got, want := postings[0].Amount, int32(300); got != want {
+		t.Errorf("posting has wrong amount: got %v expected %v", got, want)
+	}
+}
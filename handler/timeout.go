@@ -0,0 +1,21 @@
+package handler
+
+import "time"
+
+// defaultHandlerTimeout is how long AddTransactionHandler, SpendPointsHandler,
+// and PayerPointsHandler give a request to complete - via a context derived
+// from r.Context() - before abandoning it. It protects against a slow or
+// wedged Store holding a request (and, for the spend/save paths, the
+// points package's stateMu write lock) open indefinitely.
+const defaultHandlerTimeout = 5 * time.Second
+
+// handlerTimeout is the deadline actually applied; SetHandlerTimeout
+// overrides it from its defaultHandlerTimeout default.
+var handlerTimeout = defaultHandlerTimeout
+
+// SetHandlerTimeout overrides the per-handler deadline applied to the
+// points-backed routes. Tests use it to exercise timeout behavior without
+// waiting out the real default.
+func SetHandlerTimeout(d time.Duration) {
+	handlerTimeout = d
+}
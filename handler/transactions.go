@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tboddyspargo/fetch/log"
+	"github.com/tboddyspargo/fetch/points"
+)
+
+// defaultTransactionsLimit is how many Transactions ListTransactionsHandler
+// returns when the request doesn't specify a "limit" query parameter.
+// maxTransactionsLimit caps how many it will ever return in one response,
+// regardless of what a caller asks for.
+const (
+	defaultTransactionsLimit = 100
+	maxTransactionsLimit     = 1000
+)
+
+// ListTransactionsHandler provides an http response listing Transactions,
+// oldest first, optionally filtered by the "payer" and "since" (RFC3339
+// timestamp) query parameters. The result is paginated via "limit" (default
+// defaultTransactionsLimit, capped at maxTransactionsLimit) and "offset"
+// (default 0), applied after filtering; the response's X-Total-Count header
+// reports how many filtered Transactions exist in total, so a caller knows
+// whether to request another page.
+func ListTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		transactions, err := points.GetTransactions()
+		if err != nil {
+			log.Error(err)
+			respondWithJSON(w, http.StatusInternalServerError, map[string]string{"errors": err.Error()})
+			return
+		}
+
+		payer := r.URL.Query().Get("payer")
+		var since time.Time
+		if s := r.URL.Query().Get("since"); s != "" {
+			parsed, parseErr := time.Parse(time.RFC3339, s)
+			if parseErr != nil {
+				log.Error(parseErr)
+				respondWithJSON(w, http.StatusBadRequest, map[string]string{"errors": parseErr.Error()})
+				return
+			}
+			since = parsed
+		}
+
+		limit, offset, err := parsePagination(r.URL.Query())
+		if err != nil {
+			log.Error(err)
+			respondWithJSON(w, http.StatusBadRequest, map[string]string{"errors": err.Error()})
+			return
+		}
+
+		filtered := make([]points.Transaction, 0, len(transactions))
+		for _, t := range transactions {
+			if payer != "" && t.Payer != payer {
+				continue
+			}
+			if !since.IsZero() && t.Timestamp.Before(since) {
+				continue
+			}
+			filtered = append(filtered, t)
+		}
+
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(filtered)))
+		respondWithJSON(w, http.StatusOK, paginate(filtered, limit, offset))
+	default:
+		methodErr := "ListTransactionsHandler only supports GET requests"
+		log.Error(methodErr)
+		respondWithJSON(w, http.StatusMethodNotAllowed, struct{}{})
+	}
+}
+
+// parsePagination reads "limit" and "offset" from query, defaulting to
+// defaultTransactionsLimit and 0 respectively. It errors on a negative
+// offset or a limit that isn't a positive integer; limit is silently capped
+// at maxTransactionsLimit rather than rejected.
+func parsePagination(query url.Values) (limit, offset int, err error) {
+	limit = defaultTransactionsLimit
+	if s := query.Get("limit"); s != "" {
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 1 {
+			return 0, 0, fmt.Errorf("invalid limit %q: must be a positive integer", s)
+		}
+		if limit > maxTransactionsLimit {
+			limit = maxTransactionsLimit
+		}
+	}
+	if s := query.Get("offset"); s != "" {
+		offset, err = strconv.Atoi(s)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset %q: must be a non-negative integer", s)
+		}
+	}
+	return limit, offset, nil
+}
+
+// paginate returns the [offset, offset+limit) slice of transactions, clamped
+// to its bounds - an offset past the end yields an empty (not nil) slice.
+func paginate(transactions []points.Transaction, limit, offset int) []points.Transaction {
+	if offset >= len(transactions) {
+		return []points.Transaction{}
+	}
+	end := offset + limit
+	if end > len(transactions) {
+		end = len(transactions)
+	}
+	return transactions[offset:end]
+}
+
+// reverseTransactionRequest is the optional JSON body for ReverseTransactionHandler.
+type reverseTransactionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ReverseTransactionHandler provides an http action, mounted at
+// /transactions/{id}/reverse, for creating a compensating Transaction that
+// cancels out the Transaction identified by {id}.
+func ReverseTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "POST":
+		id, ok := parseReverseTransactionPath(r.URL.Path)
+		if !ok {
+			respondWithJSON(w, http.StatusNotFound, map[string]string{"errors": "unrecognized path; expected /transactions/{id}/reverse"})
+			return
+		}
+
+		var body reverseTransactionRequest
+		// The reason is optional, so a missing or empty body is not an error.
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		compensating, err := points.ReverseTransaction(id, body.Reason)
+		if err != nil {
+			respondWithPointsError(w, err)
+			return
+		}
+		respondWithJSON(w, http.StatusCreated, compensating)
+	default:
+		methodErr := "ReverseTransactionHandler only supports POST requests"
+		log.Error(methodErr)
+		respondWithJSON(w, http.StatusMethodNotAllowed, struct{}{})
+	}
+}
+
+// parseReverseTransactionPath extracts the Transaction id from a path of the
+// form /transactions/{id}/reverse.
+func parseReverseTransactionPath(path string) (int32, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 3 || parts[0] != "transactions" || parts[2] != "reverse" {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int32(id), true
+}
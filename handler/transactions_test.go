@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tboddyspargo/fetch/points"
+)
+
+func seedTransactions(t *testing.T, n int) {
+	t.Helper()
+	points.ResetTransactions()
+	for i := 0; i < n; i++ {
+		tr, err := points.NewTransaction("DANNON", 100, "2020-10-31T15:00:00Z")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tr.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestListTransactionsHandlerPaginatesWithDefaultLimit(t *testing.T) {
+	seedTransactions(t, defaultTransactionsLimit+10)
+
+	req := httptest.NewRequest("GET", "/transactions", nil)
+	rec := httptest.NewRecorder()
+	ListTransactionsHandler(rec, req)
+
+	if got, want := rec.Code, http.StatusOK; got != want {
+		t.Fatalf("got status %v; want %v", got, want)
+	}
+	if got, want := rec.Header().Get("X-Total-Count"), "110"; got != want {
+		t.Errorf("got X-Total-Count %v; want %v", got, want)
+	}
+	var transactions []points.Transaction
+	if err := json.Unmarshal(rec.Body.Bytes(), &transactions); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(transactions), defaultTransactionsLimit; got != want {
+		t.Errorf("got %v transactions; want the default page size %v", got, want)
+	}
+}
+
+func TestListTransactionsHandlerLimitAndOffset(t *testing.T) {
+	seedTransactions(t, 5)
+	all, err := points.GetTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/transactions?limit=2&offset=3", nil)
+	rec := httptest.NewRecorder()
+	ListTransactionsHandler(rec, req)
+
+	if got, want := rec.Code, http.StatusOK; got != want {
+		t.Fatalf("got status %v; want %v", got, want)
+	}
+	var transactions []points.Transaction
+	if err := json.Unmarshal(rec.Body.Bytes(), &transactions); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(transactions), 2; got != want {
+		t.Fatalf("got %v transactions; want %v", got, want)
+	}
+	if got, want := transactions[0].ID, all[3].ID; got != want {
+		t.Errorf("got first transaction id %v; want %v (the 4th of 5, 0-indexed offset 3)", got, want)
+	}
+
+	req = httptest.NewRequest("GET", "/transactions?limit=2&offset=10", nil)
+	rec = httptest.NewRecorder()
+	ListTransactionsHandler(rec, req)
+	transactions = nil
+	if err := json.Unmarshal(rec.Body.Bytes(), &transactions); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(transactions), 0; got != want {
+		t.Errorf("offset past the end: got %v transactions; want %v", got, want)
+	}
+}
+
+func TestListTransactionsHandlerRejectsInvalidPagination(t *testing.T) {
+	seedTransactions(t, 1)
+
+	for _, query := range []string{"limit=0", "limit=abc", "offset=-1", "offset=abc"} {
+		req := httptest.NewRequest("GET", "/transactions?"+query, nil)
+		rec := httptest.NewRecorder()
+		ListTransactionsHandler(rec, req)
+		if got, want := rec.Code, http.StatusBadRequest; got != want {
+			t.Errorf("query %q: got status %v; want %v", query, got, want)
+		}
+	}
+}
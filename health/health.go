@@ -0,0 +1,278 @@
+// Package health tracks the in-flight request count and recent error rate
+// for the fetch service so that health-check endpoints can report real
+// status instead of a hard-coded "everything is fine".
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// These constants mirror the enumerated statuses the service has always
+// exposed in its HealthCheck response, now backed by real signal.
+const (
+	IdleStatus = iota
+	BusyStatus
+	ErrorStatus
+	NotRunningStatus
+)
+
+// defaultErrorWindow is how far back RecordError looks when computing the
+// rolling error rate used to decide ErrorStatus.
+const defaultErrorWindow = 1 * time.Minute
+
+// Checker is a named readiness probe. Check should return nil when the
+// dependency it covers is reachable and an error otherwise; Readiness runs
+// every registered Checker and reports ErrorStatus if any of them fail.
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// CheckResult is the JSON-serializable outcome of running a single Checker.
+type CheckResult struct {
+	Name      string `json:"name"`
+	OK        bool   `json:"ok"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report is the JSON-serializable snapshot returned by health endpoints.
+type Report struct {
+	Status           int           `json:"status"`
+	UptimeSeconds    float64       `json:"uptime_seconds"`
+	TransactionCount int           `json:"transaction_count"`
+	LastError        string        `json:"last_error,omitempty"`
+	Checks           []CheckResult `json:"checks,omitempty"`
+}
+
+// Tracker accumulates the signals (in-flight requests, recent errors) that
+// determine a service's health status.
+type Tracker struct {
+	mu sync.Mutex
+
+	startedAt time.Time
+
+	inFlight      int
+	busyThreshold int
+
+	// spendInFlight and spendBusyThreshold track in-flight spends
+	// separately from the general inFlight counter above, so readiness can
+	// warn specifically about spend traffic backing up (e.g. due to
+	// lock contention on SpendAcrossPayers) without false-positiving on
+	// read-heavy traffic. A zero threshold disables the check.
+	spendInFlight      int
+	spendBusyThreshold int
+
+	errorWindow    time.Duration
+	errorThreshold int
+	errorTimes     []time.Time
+	lastError      string
+
+	shuttingDown bool
+
+	// transactionCount, when set, is consulted for the Report's
+	// TransactionCount field. It's a func rather than a direct dependency
+	// on the points package to avoid an import cycle / tight coupling.
+	transactionCount func() int
+
+	checkers []Checker
+}
+
+// New constructs a Tracker. busyThreshold is the number of simultaneously
+// in-flight requests above which the service reports BusyStatus.
+// errorThreshold is the number of errors within the last minute above which
+// the service reports ErrorStatus.
+func New(busyThreshold, errorThreshold int) *Tracker {
+	return &Tracker{
+		startedAt:      time.Now(),
+		busyThreshold:  busyThreshold,
+		errorWindow:    defaultErrorWindow,
+		errorThreshold: errorThreshold,
+	}
+}
+
+// SetTransactionCounter registers a function used to populate the
+// TransactionCount field of Report.
+func (t *Tracker) SetTransactionCounter(f func() int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.transactionCount = f
+}
+
+// BeginRequest marks the start of an in-flight request and returns a func
+// that must be called (typically via defer) when the request completes.
+func (t *Tracker) BeginRequest() func() {
+	t.mu.Lock()
+	t.inFlight++
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		t.inFlight--
+		t.mu.Unlock()
+	}
+}
+
+// BeginSpend marks the start of an in-flight spend and returns a func that
+// must be called (typically via defer) when it completes. See
+// spendBusyThreshold for why this is tracked separately from BeginRequest.
+func (t *Tracker) BeginSpend() func() {
+	t.mu.Lock()
+	t.spendInFlight++
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		t.spendInFlight--
+		t.mu.Unlock()
+	}
+}
+
+// SetBusyThreshold sets the number of simultaneously in-flight requests
+// above which Report reports BusyStatus.
+func (t *Tracker) SetBusyThreshold(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.busyThreshold = n
+}
+
+// SetErrorThreshold sets the number of errors within the last minute above
+// which Report reports ErrorStatus.
+func (t *Tracker) SetErrorThreshold(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errorThreshold = n
+}
+
+// SetSpendBusyThreshold sets the number of simultaneously in-flight spends
+// above which Readiness reports BusyStatus. A threshold of 0 (the default)
+// disables this check.
+func (t *Tracker) SetSpendBusyThreshold(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spendBusyThreshold = n
+}
+
+// RegisterChecker adds c to the set of probes Readiness runs.
+func (t *Tracker) RegisterChecker(c Checker) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.checkers = append(t.checkers, c)
+}
+
+// RecordError records that a handler encountered err, for use in the rolling
+// error-rate calculation and as the Report's LastError.
+func (t *Tracker) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errorTimes = append(t.errorTimes, time.Now())
+	t.lastError = err.Error()
+}
+
+// Shutdown marks the service as shutting down so liveness checks start
+// reporting NotRunningStatus.
+func (t *Tracker) Shutdown() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.shuttingDown = true
+}
+
+// recentErrorCount returns how many errors were recorded within errorWindow
+// of now, discarding older entries. Callers must hold t.mu.
+func (t *Tracker) recentErrorCount(now time.Time) int {
+	cutoff := now.Add(-t.errorWindow)
+	kept := t.errorTimes[:0]
+	for _, at := range t.errorTimes {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	t.errorTimes = kept
+	return len(kept)
+}
+
+// Report returns a snapshot of the tracker's current status.
+func (t *Tracker) Report() Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r := Report{
+		UptimeSeconds: time.Since(t.startedAt).Seconds(),
+		LastError:     t.lastError,
+	}
+	if t.transactionCount != nil {
+		r.TransactionCount = t.transactionCount()
+	}
+
+	switch {
+	case t.shuttingDown:
+		r.Status = NotRunningStatus
+	case t.recentErrorCount(time.Now()) > t.errorThreshold:
+		r.Status = ErrorStatus
+	case t.inFlight > t.busyThreshold:
+		r.Status = BusyStatus
+	default:
+		r.Status = IdleStatus
+	}
+	return r
+}
+
+// runCheckers runs every registered Checker and returns its outcome. It
+// takes its own copy of t.checkers under lock so that the (potentially
+// slow) Check calls themselves don't hold t.mu.
+func (t *Tracker) runCheckers(ctx context.Context) []CheckResult {
+	t.mu.Lock()
+	checkers := append([]Checker(nil), t.checkers...)
+	t.mu.Unlock()
+
+	results := make([]CheckResult, 0, len(checkers))
+	for _, c := range checkers {
+		start := time.Now()
+		err := c.Check(ctx)
+		result := CheckResult{Name: c.Name(), OK: err == nil, LatencyMS: time.Since(start).Milliseconds()}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Readiness runs every registered Checker and folds the result into a
+// Report: ErrorStatus if any Checker failed, BusyStatus if spend traffic has
+// backed up past SetSpendBusyThreshold, and otherwise the same status
+// Report would have returned on its own. It never reports NotRunningStatus
+// itself - that's still driven by Shutdown via Report - but it also never
+// downgrades it.
+func (t *Tracker) Readiness(ctx context.Context) Report {
+	checks := t.runCheckers(ctx)
+	report := t.Report()
+	report.Checks = checks
+
+	if report.Status == NotRunningStatus {
+		return report
+	}
+	for _, c := range checks {
+		if !c.OK {
+			report.Status = ErrorStatus
+			return report
+		}
+	}
+
+	t.mu.Lock()
+	spendBusy := t.spendBusyThreshold > 0 && t.spendInFlight > t.spendBusyThreshold
+	t.mu.Unlock()
+	if spendBusy {
+		report.Status = BusyStatus
+	}
+	return report
+}
+
+// Default is the Tracker used by the handler package's HTTP endpoints
+// unless a different Tracker is substituted.
+var Default = New(10, 5)
@@ -0,0 +1,59 @@
+package health
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTrackerReportIdleByDefault(t *testing.T) {
+	tr := New(1, 1)
+	if got, want := tr.Report().Status, IdleStatus; got != want {
+		t.Errorf("got %v; expected %v", got, want)
+	}
+}
+
+func TestTrackerReportBusy(t *testing.T) {
+	tr := New(1, 1)
+	end1 := tr.BeginRequest()
+	end2 := tr.BeginRequest()
+	defer end1()
+	defer end2()
+
+	if got, want := tr.Report().Status, BusyStatus; got != want {
+		t.Errorf("got %v; expected %v", got, want)
+	}
+}
+
+func TestTrackerReportError(t *testing.T) {
+	tr := New(10, 1)
+	tr.RecordError(errors.New("boom"))
+	tr.RecordError(errors.New("boom again"))
+
+	report := tr.Report()
+	if got, want := report.Status, ErrorStatus; got != want {
+		t.Errorf("got %v; expected %v", got, want)
+	}
+	if got, want := report.LastError, "boom again"; got != want {
+		t.Errorf("got %v; expected %v", got, want)
+	}
+}
+
+func TestTrackerReportNotRunningAfterShutdown(t *testing.T) {
+	tr := New(10, 10)
+	tr.Shutdown()
+	if got, want := tr.Report().Status, NotRunningStatus; got != want {
+		t.Errorf("got %v; expected %v", got, want)
+	}
+}
+
+func TestTrackerBeginRequestDecrementsOnEnd(t *testing.T) {
+	tr := New(0, 1)
+	end := tr.BeginRequest()
+	if got, want := tr.Report().Status, BusyStatus; got != want {
+		t.Errorf("got %v; expected %v", got, want)
+	}
+	end()
+	if got, want := tr.Report().Status, IdleStatus; got != want {
+		t.Errorf("got %v; expected %v", got, want)
+	}
+}
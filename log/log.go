@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 )
 
@@ -16,6 +17,34 @@ const (
 	defaultErrorPrefix = "ERROR: "
 )
 
+// LevelInfo and LevelError are the two severities SetLevel accepts. Only
+// LevelError suppresses anything: it silences Info/Infof so that a noisy
+// deployment can be quieted down through /config without a restart.
+// Error/Errorf/Fatal always write regardless of level.
+const (
+	LevelInfo  = "info"
+	LevelError = "error"
+)
+
+var (
+	levelMu sync.Mutex
+	level   = LevelInfo
+)
+
+// SetLevel sets the minimum severity Info/Infof will actually write. Any
+// value other than LevelError is treated as LevelInfo, the default.
+func SetLevel(l string) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	level = l
+}
+
+func currentLevel() string {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	return level
+}
+
 // These variables provide access to global logger objects that will be initialized on startup and used throughout the code.
 var (
 	InfoLogger      *log.Logger
@@ -52,6 +81,9 @@ func Infof(formatString string, values ...interface{}) {
 }
 
 func Info(message ...interface{}) {
+	if currentLevel() == LevelError {
+		return
+	}
 	InfoLogger.Println(message...)
 }
 
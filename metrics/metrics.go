@@ -0,0 +1,169 @@
+// Package metrics is a minimal Prometheus text-exposition-format (v0.0.4)
+// registry. It implements just enough of the Prometheus client model -
+// single-label Counters and Gauges, and a two-label fixed-bucket Histogram -
+// to back the handler package's /metrics endpoint, rather than pulling in
+// the full client library for a handful of series.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Counter is a monotonically increasing value, partitioned by a single
+// label (e.g. payer, result).
+type Counter struct {
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewCounter constructs an empty Counter.
+func NewCounter() *Counter {
+	return &Counter{values: make(map[string]float64)}
+}
+
+// Inc increments the counter for label by 1.
+func (c *Counter) Inc(label string) { c.Add(label, 1) }
+
+// Add increments the counter for label by delta.
+func (c *Counter) Add(label string, delta float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[label] += delta
+}
+
+// WriteTo emits name's HELP/TYPE header followed by one series per observed
+// label value, in Prometheus text exposition format. labelName is the
+// Prometheus label key (e.g. "payer"); passing "" omits labels entirely,
+// producing a single unlabeled series.
+func (c *Counter) WriteTo(w io.Writer, name, help, labelName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, label := range sortedKeys(c.values) {
+		writeSeries(w, name, labelName, label, c.values[label])
+	}
+}
+
+// Gauge is a value that can move up or down, partitioned by a single label.
+type Gauge struct {
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewGauge constructs an empty Gauge.
+func NewGauge() *Gauge {
+	return &Gauge{values: make(map[string]float64)}
+}
+
+// Set records value as the current reading for label, replacing whatever
+// was previously set.
+func (g *Gauge) Set(label string, value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[label] = value
+}
+
+// WriteTo emits name's HELP/TYPE header followed by one series per observed
+// label value, as described on Counter.WriteTo.
+func (g *Gauge) WriteTo(w io.Writer, name, help, labelName string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	for _, label := range sortedKeys(g.values) {
+		writeSeries(w, name, labelName, label, g.values[label])
+	}
+}
+
+// DefaultBuckets are the cumulative bucket boundaries (in seconds) used by
+// Histogram, matching the Prometheus client library's own defaults.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogramSeries accumulates the observations for one (handler, code)
+// label pair: a running count per bucket upper bound, plus the overall sum
+// and count needed to derive an average.
+type histogramSeries struct {
+	buckets []float64
+	sum     float64
+	count   float64
+}
+
+// Histogram observes float64 values (e.g. request duration in seconds) into
+// DefaultBuckets, partitioned by a fixed "handler" and "code" label pair.
+type Histogram struct {
+	mu     sync.Mutex
+	series map[[2]string]*histogramSeries
+}
+
+// NewHistogram constructs an empty Histogram using DefaultBuckets.
+func NewHistogram() *Histogram {
+	return &Histogram{series: make(map[[2]string]*histogramSeries)}
+}
+
+// Observe records value against the (handler, code) label pair.
+func (h *Histogram) Observe(handler, code string, value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := [2]string{handler, code}
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{buckets: make([]float64, len(DefaultBuckets))}
+		h.series[key] = s
+	}
+	for i, bound := range DefaultBuckets {
+		if value <= bound {
+			s.buckets[i]++
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+// WriteTo emits name's HELP/TYPE header followed by the cumulative bucket,
+// sum, and count series for every (handler, code) pair observed so far.
+func (h *Histogram) WriteTo(w io.Writer, name, help string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	keys := make([][2]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, k := range keys {
+		s := h.series[k]
+		handlerLabel, codeLabel := k[0], k[1]
+		for i, bound := range DefaultBuckets {
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			fmt.Fprintf(w, "%s_bucket{handler=%q,code=%q,le=%q} %v\n", name, handlerLabel, codeLabel, le, s.buckets[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{handler=%q,code=%q,le=\"+Inf\"} %v\n", name, handlerLabel, codeLabel, s.count)
+		fmt.Fprintf(w, "%s_sum{handler=%q,code=%q} %v\n", name, handlerLabel, codeLabel, s.sum)
+		fmt.Fprintf(w, "%s_count{handler=%q,code=%q} %v\n", name, handlerLabel, codeLabel, s.count)
+	}
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeSeries(w io.Writer, name, labelName, label string, value float64) {
+	if labelName == "" {
+		fmt.Fprintf(w, "%s %v\n", name, value)
+		return
+	}
+	fmt.Fprintf(w, "%s{%s=%q} %v\n", name, labelName, label, value)
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCounterWriteTo(t *testing.T) {
+	c := NewCounter()
+	c.Inc("DANNON")
+	c.Add("DANNON", 2)
+	c.Inc("UNILEVER")
+
+	var buf bytes.Buffer
+	c.WriteTo(&buf, "fetch_transactions_total", "Total number of transactions recorded, by payer.", "payer")
+
+	out := buf.String()
+	if !strings.Contains(out, `fetch_transactions_total{payer="DANNON"} 3`) {
+		t.Errorf("expected DANNON series with value 3, got:\n%s", out)
+	}
+	if !strings.Contains(out, `fetch_transactions_total{payer="UNILEVER"} 1`) {
+		t.Errorf("expected UNILEVER series with value 1, got:\n%s", out)
+	}
+}
+
+func TestCounterWriteToUnlabeled(t *testing.T) {
+	c := NewCounter()
+	c.Add("", 42)
+
+	var buf bytes.Buffer
+	c.WriteTo(&buf, "fetch_spend_points_total", "Total number of points spent across all payers.", "")
+
+	if !strings.Contains(buf.String(), "fetch_spend_points_total 42") {
+		t.Errorf("expected unlabeled series, got:\n%s", buf.String())
+	}
+}
+
+func TestGaugeWriteTo(t *testing.T) {
+	g := NewGauge()
+	g.Set("DANNON", 100)
+	g.Set("DANNON", 300)
+
+	var buf bytes.Buffer
+	g.WriteTo(&buf, "fetch_transaction_points_sum", "Current point balance for each payer.", "payer")
+
+	if !strings.Contains(buf.String(), `fetch_transaction_points_sum{payer="DANNON"} 300`) {
+		t.Errorf("expected latest Set value, got:\n%s", buf.String())
+	}
+}
+
+func TestHistogramWriteTo(t *testing.T) {
+	h := NewHistogram()
+	h.Observe("AddTransactionHandler", "201", 0.02)
+	h.Observe("AddTransactionHandler", "201", 0.2)
+
+	var buf bytes.Buffer
+	h.WriteTo(&buf, "fetch_http_request_duration_seconds", "HTTP request latency in seconds, by handler and response code.")
+
+	out := buf.String()
+	if !strings.Contains(out, `fetch_http_request_duration_seconds_count{handler="AddTransactionHandler",code="201"} 2`) {
+		t.Errorf("expected count of 2, got:\n%s", out)
+	}
+	if !strings.Contains(out, `fetch_http_request_duration_seconds_bucket{handler="AddTransactionHandler",code="201",le="0.025"} 1`) {
+		t.Errorf("expected one observation in the 0.025 bucket, got:\n%s", out)
+	}
+	if !strings.Contains(out, `fetch_http_request_duration_seconds_bucket{handler="AddTransactionHandler",code="201",le="+Inf"} 2`) {
+		t.Errorf("expected both observations in the +Inf bucket, got:\n%s", out)
+	}
+}
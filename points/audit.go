@@ -0,0 +1,139 @@
+package points
+
+import (
+	"fmt"
+	"time"
+)
+
+// AuditRecord is the immutable-once-reversed audit trail entry for a single
+// Transaction, letting operators trace how a payer's balance evolved.
+type AuditRecord struct {
+	TransactionID int32     `json:"transaction_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	// ReversedBy is the id of the compensating Transaction created by
+	// ReverseTransaction, or nil if this Transaction has not been reversed.
+	ReversedBy *int32 `json:"reversed_by,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// GetAuditTrail returns the audit trail entry for the Transaction identified
+// by id, if one exists.
+func GetAuditTrail(id int32) (AuditRecord, bool, error) {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	return defaultStore.GetAuditTrail(id)
+}
+
+// findTransactionByIDLocked scans the active Store for the Transaction with
+// the given id. Callers must already hold stateMu.
+func findTransactionByIDLocked(id int32) (Transaction, bool, error) {
+	transactions, err := defaultStore.ListTransactionsByTimestamp()
+	if err != nil {
+		return Transaction{}, false, err
+	}
+	for _, t := range transactions {
+		if t.ID == id {
+			return t, true, nil
+		}
+	}
+	return Transaction{}, false, nil
+}
+
+// ReverseTransaction creates a compensating Transaction that cancels out the
+// Transaction identified by id: an award is offset by an equal spend-style
+// debit, and a spend is offset by an equal award-style credit. It guards
+// against driving the payer's balance below zero exactly as SpendPoints
+// does, and records reason against the original Transaction's audit trail.
+// A Transaction may only be reversed once.
+//
+// Reversing a spend (orig.userInitiated) also offsets every Posting that
+// spend recorded in the spend journal - see reversePostingsLocked - so that
+// Verify()'s recomputation, which treats userInitiated Transactions as
+// opaque and relies entirely on Postings to track what they did, doesn't
+// flag the reversal as drift.
+func ReverseTransaction(id int32, reason string) (Transaction, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	orig, found, err := findTransactionByIDLocked(id)
+	if err != nil {
+		return Transaction{}, E("points.ReverseTransaction", KindStorage, err, id)
+	}
+	if !found {
+		return Transaction{}, E("points.ReverseTransaction", KindValidation, fmt.Errorf("no transaction found with id %v", id), id)
+	}
+
+	audit, hasAudit, err := defaultStore.GetAuditTrail(id)
+	if err != nil {
+		return Transaction{}, E("points.ReverseTransaction", KindStorage, err, orig.Payer, id)
+	}
+	if hasAudit && audit.ReversedBy != nil {
+		alreadyErr := fmt.Errorf("transaction %v has already been reversed by transaction %v", id, *audit.ReversedBy)
+		return Transaction{}, E("points.ReverseTransaction", KindConflict, alreadyErr, orig.Payer, id)
+	}
+
+	payerTotals, err := defaultStore.GetPayerTotals()
+	if err != nil {
+		return Transaction{}, E("points.ReverseTransaction", KindStorage, err, orig.Payer, id)
+	}
+	if payerTotals[orig.Payer]-orig.Points < 0 {
+		negErr := fmt.Errorf("cannot reverse transaction %v: would bring payer %v's balance below zero. available: %v, reversal: %v", id, orig.Payer, payerTotals[orig.Payer], -orig.Points)
+		return Transaction{}, E("points.ReverseTransaction", KindInsufficientFunds, negErr, orig.Payer, id)
+	}
+
+	compensating := Transaction{Payer: orig.Payer, Points: -orig.Points, Timestamp: time.Now(), userInitiated: orig.userInitiated}
+	if err := compensating.saveLocked(); err != nil {
+		return Transaction{}, err
+	}
+
+	if orig.userInitiated {
+		if err := reversePostingsLocked(id, compensating); err != nil {
+			return Transaction{}, E("points.ReverseTransaction", KindStorage, err, orig.Payer, id)
+		}
+	}
+
+	reversedBy := compensating.ID
+	if !hasAudit {
+		audit = AuditRecord{TransactionID: id, CreatedAt: orig.Timestamp}
+	}
+	audit.ReversedBy = &reversedBy
+	audit.Reason = reason
+	if err := defaultStore.RecordAudit(audit); err != nil {
+		return Transaction{}, E("points.ReverseTransaction", KindStorage, err, orig.Payer, id)
+	}
+	if err := defaultStore.RecordAudit(AuditRecord{TransactionID: compensating.ID, CreatedAt: compensating.Timestamp}); err != nil {
+		return Transaction{}, E("points.ReverseTransaction", KindStorage, err, orig.Payer, compensating.ID)
+	}
+
+	return compensating, nil
+}
+
+// reversePostingsLocked offsets every Posting recorded against reversedTxID
+// - the userInitiated Transaction being reversed - with a negated-amount
+// Posting attributed to compensating, the Transaction created to reverse it.
+// Without this, reversing a spend would change payer balances without
+// leaving any trace in the spend journal, since Verify() only sums
+// Postings to account for what userInitiated Transactions did. Callers must
+// already hold stateMu's write lock.
+func reversePostingsLocked(reversedTxID int32, compensating Transaction) error {
+	postings, err := defaultStore.ListPostings()
+	if err != nil {
+		return err
+	}
+	for _, p := range postings {
+		if p.CompensatingTxID != reversedTxID {
+			continue
+		}
+		reversal := Posting{
+			SourceTxID:       p.SourceTxID,
+			Payer:            p.Payer,
+			Amount:           -p.Amount,
+			Timestamp:        compensating.Timestamp,
+			CompensatingTxID: compensating.ID,
+		}
+		if err := defaultStore.AppendPosting(reversal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package points
+
+import "testing"
+
+func TestReverseTransaction(t *testing.T) {
+	ResetTransactions()
+
+	tr, _ := NewTransaction("DANNON", 1000, "2020-10-31T15:00:00Z")
+	tr.Save()
+
+	compensating, err := ReverseTransaction(tr.ID, "awarded by mistake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := compensating.Points, -tr.Points; got != want {
+		t.Errorf("compensating transaction has wrong points: got %v expected %v", got, want)
+	}
+
+	totals, _ := GetPayerTotals()
+	if got, want := totals["DANNON"], int32(0); got != want {
+		t.Errorf("reversal didn't zero out the payer's balance: got %v expected %v", got, want)
+	}
+
+	audit, ok, err := GetAuditTrail(tr.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected an audit record for the reversed transaction")
+	}
+	if audit.ReversedBy == nil || *audit.ReversedBy != compensating.ID {
+		t.Errorf("audit record doesn't reference the compensating transaction: got %v expected %v", audit.ReversedBy, compensating.ID)
+	}
+	if got, want := audit.Reason, "awarded by mistake"; got != want {
+		t.Errorf("audit record has wrong reason: got %v expected %v", got, want)
+	}
+}
+
+func TestReverseTransactionTwiceFails(t *testing.T) {
+	ResetTransactions()
+
+	tr, _ := NewTransaction("DANNON", 1000, "2020-10-31T15:00:00Z")
+	tr.Save()
+
+	if _, err := ReverseTransaction(tr.ID, "first reversal"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReverseTransaction(tr.ID, "second reversal"); err == nil {
+		t.Error("expected an error when reversing an already-reversed transaction, got nil")
+	}
+}
+
+func TestReverseTransactionGuardsAgainstNegativeBalance(t *testing.T) {
+	ResetTransactions()
+
+	tr, _ := NewTransaction("DANNON", 1000, "2020-10-31T15:00:00Z")
+	tr.Save()
+	tr.SpendPoints(800)
+
+	if _, err := ReverseTransaction(tr.ID, "would go negative"); err == nil {
+		t.Error("expected an error reversing an award that's already been partially spent below the reversal amount, got nil")
+	}
+}
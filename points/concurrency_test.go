@@ -0,0 +1,117 @@
+package points
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestSpendPointsConcurrent fires many goroutines spending against the same
+// payer concurrently and asserts the payer's balance never goes negative and
+// that the sum of points actually spent matches what SpendPoints reported
+// back to callers. Run with `go test -race` to confirm there's no data race
+// in the underlying Store.
+func TestSpendPointsConcurrent(t *testing.T) {
+	ResetTransactions()
+
+	tr, err := NewTransaction("DANNON", 10000, "2020-10-31T15:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	const goroutines = 100
+	const spendPerGoroutine = 150
+
+	var wg sync.WaitGroup
+	var totalSpent int32
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			spent, err := tr.SpendPoints(spendPerGoroutine)
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&totalSpent, spent)
+		}()
+	}
+	wg.Wait()
+
+	totals, err := GetPayerTotals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if totals["DANNON"] < 0 {
+		t.Fatalf("payer balance went negative: got %v", totals["DANNON"])
+	}
+	if got, want := totals["DANNON"], tr.Points-totalSpent; got != want {
+		t.Errorf("payer balance doesn't reflect total spent: got %v expected %v", got, want)
+	}
+}
+
+// TestConcurrentSaveAndSpend fires many goroutines concurrently saving new
+// Transactions for several payers and spending against one of them,
+// asserting two invariants that must hold no matter how the goroutines are
+// interleaved: the sum of every payer's balance always equals
+// TotalAvailable(), and no Transaction is ever recorded as having had more
+// spent from it than it was worth. Run with `go test -race` to confirm
+// there's no data race in the underlying Store.
+func TestConcurrentSaveAndSpend(t *testing.T) {
+	ResetTransactions()
+
+	payers := []string{"DANNON", "UNILEVER", "MILLER COORS"}
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tr, err := NewTransaction(payers[i%len(payers)], 100, "2020-10-31T15:00:00Z")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if err := tr.Save(); err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := tr.SpendPoints(25); err != nil {
+				return
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	totals, err := GetPayerTotals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sum int32
+	for _, total := range totals {
+		sum += total
+	}
+	available, err := TotalAvailable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != available {
+		t.Errorf("sum(payerTotals) disagrees with TotalAvailable(): got %v expected %v", sum, available)
+	}
+
+	transactions, err := GetTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spentTransactions, err := GetSpentTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tr := range transactions {
+		if spent, ok := spentTransactions[tr.ID]; ok && spent > tr.Points {
+			t.Errorf("transaction %v had more spent from it (%v) than it was worth (%v)", tr.ID, spent, tr.Points)
+		}
+	}
+}
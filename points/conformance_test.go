@@ -0,0 +1,132 @@
+package points
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// conformanceVectorTransaction is one Transaction to seed a conformance
+// vector with, as it appears in a testdata/vectors/*.json file.
+type conformanceVectorTransaction struct {
+	Payer     string `json:"payer"`
+	Points    int32  `json:"points"`
+	Timestamp string `json:"timestamp"`
+}
+
+// conformanceVectorSpend is one SpendRequest to replay against a
+// conformance vector's seeded Transactions, along with the result
+// SpendAcrossPayers is expected to produce.
+//
+// ExpectErrorStatus matches the field name handler/conformance_test.go
+// uses for the same vector files, but means something different here:
+// SpendAcrossPayers has no equivalent of the handler's upfront "is there
+// enough available across every payer combined" check - it just spends as
+// much as actually is available - so a vector with ExpectErrorStatus set
+// (e.g. spending more than every payer's total combined) still produces a
+// normal, non-error ExpectedResult at this layer. Only SpendAcrossPayers
+// itself returning a non-nil error is treated as a points-level
+// conformance failure.
+type conformanceVectorSpend struct {
+	Points            int32          `json:"points"`
+	ExpectErrorStatus int            `json:"expect_error_status"`
+	ExpectedResult    []PayerBalance `json:"expected_result"`
+}
+
+// conformanceVector describes a self-contained spend-ordering scenario: an
+// initial set of Transactions, a sequence of spends to replay against them,
+// and the PayerTotals that should remain once every spend has run.
+type conformanceVector struct {
+	Name                string                         `json:"name"`
+	Transactions        []conformanceVectorTransaction `json:"transactions"`
+	Spends              []conformanceVectorSpend       `json:"spends"`
+	ExpectedPayerTotals map[string]int32               `json:"expected_payer_totals"`
+}
+
+// loadConformanceVectors reads every testdata/vectors/*.json file relative
+// to this package's directory.
+func loadConformanceVectors(t *testing.T) []conformanceVector {
+	t.Helper()
+	paths, err := filepath.Glob("../testdata/vectors/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no conformance vectors found under testdata/vectors")
+	}
+
+	var vectors []conformanceVector
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v conformanceVector
+		if err := json.Unmarshal(data, &v); err != nil {
+			t.Fatalf("%v: %v", path, err)
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors
+}
+
+// sortedPayerBalances converts pt to a []PayerBalance sorted by payer name,
+// so it can be compared against a vector's expected_result regardless of
+// the nondeterministic map iteration order PayerTotals.ToPayerBalances()
+// produces.
+func sortedPayerBalances(pt PayerTotals) []PayerBalance {
+	result := pt.ToPayerBalances()
+	sort.Slice(result, func(i, j int) bool { return result[i].Payer < result[j].Payer })
+	return result
+}
+
+// TestConformance replays every testdata/vectors/*.json scenario directly
+// against SpendAcrossPayers, pinning down the FIFO-oldest-first,
+// never-below-zero spend invariants without a hand-written Go test per
+// case.
+func TestConformance(t *testing.T) {
+	for _, v := range loadConformanceVectors(t) {
+		v := v
+		t.Run(v.Name, func(t *testing.T) {
+			ResetTransactions()
+
+			for _, vt := range v.Transactions {
+				tr, err := NewTransaction(vt.Payer, vt.Points, vt.Timestamp)
+				if err != nil {
+					t.Fatalf("invalid vector transaction %+v: %v", vt, err)
+				}
+				if err := tr.Save(); err != nil {
+					t.Fatalf("could not save vector transaction %+v: %v", vt, err)
+				}
+			}
+
+			for i, spend := range v.Spends {
+				result, err := SpendAcrossPayers(spend.Points)
+				if err != nil {
+					t.Fatalf("spend %v: %v", i, err)
+				}
+				if spend.ExpectedResult != nil {
+					got := sortedPayerBalances(result)
+					want := append([]PayerBalance{}, spend.ExpectedResult...)
+					sort.Slice(want, func(i, j int) bool { return want[i].Payer < want[j].Payer })
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("spend %v: result mismatch: got %+v; want %+v", i, got, want)
+					}
+				}
+			}
+
+			totals, err := GetPayerTotals()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for payer, want := range v.ExpectedPayerTotals {
+				if got := totals[payer]; got != want {
+					t.Errorf("final payer total for %v: got %v; want %v", payer, got, want)
+				}
+			}
+		})
+	}
+}
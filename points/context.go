@@ -0,0 +1,129 @@
+package points
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SaveCtx behaves like Save, but returns ctx.Err() without persisting t if
+// ctx is already done - a request whose client disconnected or whose
+// deadline has passed shouldn't still award a Transaction just because the
+// goroutine handling it eventually got here.
+func (t *Transaction) SaveCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return t.Save()
+}
+
+// GetPayerTotalsCtx behaves like GetPayerTotals, but returns ctx.Err()
+// without reading the Store if ctx is already done.
+func GetPayerTotalsCtx(ctx context.Context) (PayerTotals, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return GetPayerTotals()
+}
+
+// appliedSpend records one Transaction debited by SpendPointsCtx, so that a
+// cancellation caught mid-flight can be undone via rollbackAppliedSpendsLocked.
+type appliedSpend struct {
+	payer  string
+	txID   int32
+	amount int32
+}
+
+// SpendPointsCtx behaves like SpendAcrossPayers, but checks ctx for
+// cancellation before touching each Transaction in the spend. Unlike
+// SpendAcrossPayers, a cancellation caught mid-flight does not leave
+// whatever was already debited in place: every appliedSpend recorded before
+// ctx was noticed done is rolled back - via the same compensating
+// Transaction plus offsetting Posting mechanism ReverseTransaction uses -
+// before SpendPointsCtx returns, so a caller told the request was cancelled
+// can trust no payer's balance actually changed, not just that none changed
+// when cancellation happened to land before the first Transaction was
+// touched.
+func SpendPointsCtx(ctx context.Context, amount int32) (PayerTotals, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	transactions, err := defaultStore.ListTransactionsByTimestamp()
+	if err != nil {
+		return nil, E("points.SpendPointsCtx", KindStorage, err)
+	}
+	spendLog, err := defaultStore.GetSpendLog()
+	if err != nil {
+		return nil, E("points.SpendPointsCtx", KindStorage, err)
+	}
+	payerTotals, err := defaultStore.GetPayerTotals()
+	if err != nil {
+		return nil, E("points.SpendPointsCtx", KindStorage, err)
+	}
+
+	index := buildSpendIndex(transactions, spendLog, payerTotals)
+	var spendErr error
+	var applied []appliedSpend
+	spentPayerPoints, ctxErr := spendFromIndexCtx(ctx, index, amount, func(payer string, txID int32, spent int32) {
+		if spendErr != nil {
+			return
+		}
+		if err := defaultStore.RecordSpend(txID, spent); err != nil {
+			spendErr = E("points.SpendPointsCtx", KindStorage, err, payer, txID)
+			return
+		}
+		compensating := Transaction{Payer: payer, Points: -spent, Timestamp: time.Now(), userInitiated: true}
+		if err := compensating.saveLocked(); err != nil {
+			spendErr = err
+			return
+		}
+		posting := Posting{SourceTxID: txID, Payer: payer, Amount: spent, Timestamp: compensating.Timestamp, CompensatingTxID: compensating.ID}
+		if err := defaultStore.AppendPosting(posting); err != nil {
+			spendErr = E("points.SpendPointsCtx", KindStorage, err, payer, txID)
+			return
+		}
+		applied = append(applied, appliedSpend{payer: payer, txID: txID, amount: spent})
+	})
+	if spendErr != nil {
+		return nil, spendErr
+	}
+	if ctxErr != nil {
+		if len(applied) == 0 {
+			return nil, ctxErr
+		}
+		if err := rollbackAppliedSpendsLocked(applied); err != nil {
+			return nil, E("points.SpendPointsCtx", KindStorage, fmt.Errorf("rolling back a cancelled spend: %w", err))
+		}
+		return nil, ctxErr
+	}
+	return spentPayerPoints, nil
+}
+
+// rollbackAppliedSpendsLocked reverses every appliedSpend, most-recent
+// first, via the same compensating-Transaction-plus-Posting mechanism
+// ReverseTransaction uses: an offsetting Transaction restores the payer's
+// balance, a Posting with a negated Amount cancels out the spend's original
+// Posting so Verify() stays in agreement, and RecordSpend marks the source
+// Transaction's points unspent again. Callers must already hold stateMu's
+// write lock.
+func rollbackAppliedSpendsLocked(applied []appliedSpend) error {
+	for i := len(applied) - 1; i >= 0; i-- {
+		a := applied[i]
+		rollback := Transaction{Payer: a.payer, Points: a.amount, Timestamp: time.Now(), userInitiated: true}
+		if err := rollback.saveLocked(); err != nil {
+			return err
+		}
+		posting := Posting{SourceTxID: a.txID, Payer: a.payer, Amount: -a.amount, Timestamp: rollback.Timestamp, CompensatingTxID: rollback.ID}
+		if err := defaultStore.AppendPosting(posting); err != nil {
+			return err
+		}
+		if err := defaultStore.RecordSpend(a.txID, -a.amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
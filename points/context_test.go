@@ -0,0 +1,152 @@
+package points
+
+import (
+	"context"
+	"testing"
+)
+
+// TestSpendPointsCtxRejectsCancelledContext asserts that a context
+// cancelled before SpendPointsCtx gets to run leaves every payer's balance
+// untouched, rather than debiting the Transactions it would otherwise have
+// spent from.
+func TestSpendPointsCtxRejectsCancelledContext(t *testing.T) {
+	ResetTransactions()
+
+	tr, err := NewTransaction("DANNON", 1000, "2020-10-31T15:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := GetPayerTotals()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = SpendPointsCtx(ctx, 500)
+	if err != context.Canceled {
+		t.Fatalf("got error %v; want context.Canceled", err)
+	}
+
+	after, err := GetPayerTotals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := after["DANNON"], before["DANNON"]; got != want {
+		t.Errorf("payer balance was mutated despite a cancelled context: got %v; want %v", got, want)
+	}
+}
+
+// cancelAfterNCtx wraps a context.Context so its Err() returns nil for the
+// first n calls and context.Canceled after that, letting tests simulate a
+// context that's cancelled partway through a multi-step operation rather
+// than before it ever starts.
+type cancelAfterNCtx struct {
+	context.Context
+	n     int
+	calls int
+}
+
+func (c *cancelAfterNCtx) Err() error {
+	c.calls++
+	if c.calls > c.n {
+		return context.Canceled
+	}
+	return nil
+}
+
+// TestSpendPointsCtxRollsBackPartialSpendOnMidFlightCancellation asserts
+// that cancelling ctx after SpendPointsCtx has already debited one payer,
+// but before it reaches a second, rolls that debit back rather than leaving
+// it applied - a client told their request was cancelled should be able to
+// trust that nothing happened, not just that nothing happened if
+// cancellation landed before the first Transaction was touched.
+func TestSpendPointsCtxRollsBackPartialSpendOnMidFlightCancellation(t *testing.T) {
+	ResetTransactions()
+
+	dannon, err := NewTransaction("DANNON", 500, "2020-10-31T15:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dannon.Save(); err != nil {
+		t.Fatal(err)
+	}
+	unilever, err := NewTransaction("UNILEVER", 500, "2020-10-31T16:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unilever.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := GetPayerTotals()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// SpendPointsCtx's upfront check and the check before DANNON's
+	// Transaction both see nil; the check before UNILEVER's sees Canceled.
+	ctx := &cancelAfterNCtx{Context: context.Background(), n: 2}
+	_, err = SpendPointsCtx(ctx, 800)
+	if err != context.Canceled {
+		t.Fatalf("got error %v; want context.Canceled", err)
+	}
+
+	after, err := GetPayerTotals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := after["DANNON"], before["DANNON"]; got != want {
+		t.Errorf("DANNON's partial debit was not rolled back: got %v; want %v", got, want)
+	}
+	if got, want := after["UNILEVER"], before["UNILEVER"]; got != want {
+		t.Errorf("UNILEVER's balance should never have been touched: got %v; want %v", got, want)
+	}
+
+	if err := Verify(); err != nil {
+		t.Errorf("Verify() reported drift after a rolled-back mid-flight cancellation: %v", err)
+	}
+}
+
+// TestSaveCtxRejectsCancelledContext asserts that SaveCtx doesn't persist a
+// Transaction if ctx is already done.
+func TestSaveCtxRejectsCancelledContext(t *testing.T) {
+	ResetTransactions()
+
+	tr, err := NewTransaction("DANNON", 500, "2020-10-31T15:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := tr.SaveCtx(ctx); err != context.Canceled {
+		t.Fatalf("got error %v; want context.Canceled", err)
+	}
+
+	transactions, err := GetTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 0 {
+		t.Errorf("got %v transactions; want 0", len(transactions))
+	}
+}
+
+// TestGetPayerTotalsCtxRejectsCancelledContext asserts that
+// GetPayerTotalsCtx returns ctx.Err() instead of reading the Store once ctx
+// is done.
+func TestGetPayerTotalsCtxRejectsCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := GetPayerTotalsCtx(ctx); err != context.Canceled {
+		t.Fatalf("got error %v; want context.Canceled", err)
+	}
+}
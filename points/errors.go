@@ -0,0 +1,104 @@
+package points
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind classifies what category of failure produced an Error, so callers -
+// chiefly the handler package - can decide how to respond (e.g. which HTTP
+// status to use) without string-matching the error message.
+type Kind int
+
+const (
+	// KindOther is the zero value: returned by KindOf for an error that
+	// isn't an *Error, or an *Error constructed without an explicit Kind.
+	KindOther Kind = iota
+	// KindValidation means the caller supplied invalid or unrecognized
+	// input, such as a missing field or an id that doesn't exist.
+	KindValidation
+	// KindInsufficientFunds means the operation would bring a payer's
+	// balance below zero.
+	KindInsufficientFunds
+	// KindConflict means the operation conflicts with the current state
+	// of the Transaction it targets, such as spending an already
+	// fully-spent Transaction or reversing an already-reversed one.
+	KindConflict
+	// KindStorage means the active Store failed to read or write state.
+	KindStorage
+)
+
+// String returns Kind's lowercase, hyphenated name, as used in log lines.
+func (k Kind) String() string {
+	switch k {
+	case KindValidation:
+		return "validation"
+	case KindInsufficientFunds:
+		return "insufficient-funds"
+	case KindConflict:
+		return "conflict"
+	case KindStorage:
+		return "storage"
+	default:
+		return "other"
+	}
+}
+
+// Error is a structured error carrying the operation that failed, its Kind,
+// and - when relevant - which Payer or Transaction it concerns, so callers
+// can log and respond to it without string-matching the wrapped cause.
+type Error struct {
+	Op    string
+	Kind  Kind
+	Payer string
+	TxID  int32
+	Err   error
+}
+
+// Error satisfies the error interface by rendering Op, Kind, Payer, TxID
+// (whichever are set), and the wrapped cause's message.
+func (e *Error) Error() string {
+	msg := e.Op
+	if e.Kind != KindOther {
+		msg += fmt.Sprintf(" [%v]", e.Kind)
+	}
+	if e.Payer != "" {
+		msg += fmt.Sprintf(" payer=%v", e.Payer)
+	}
+	if e.TxID != 0 {
+		msg += fmt.Sprintf(" tx=%v", e.TxID)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap exposes the wrapped cause to errors.Is/errors.As.
+func (e *Error) Unwrap() error { return e.Err }
+
+// E constructs an *Error for op and kind, wrapping cause. Remaining
+// arguments set optional context: a string sets Payer, an int32 sets TxID.
+// Unrecognized argument types are ignored.
+func E(op string, kind Kind, cause error, kv ...interface{}) *Error {
+	e := &Error{Op: op, Kind: kind, Err: cause}
+	for _, v := range kv {
+		switch x := v.(type) {
+		case string:
+			e.Payer = x
+		case int32:
+			e.TxID = x
+		}
+	}
+	return e
+}
+
+// KindOf returns the Kind of err, following its Unwrap chain to find the
+// nearest *Error, or KindOther if err isn't (and doesn't wrap) one.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindOther
+}
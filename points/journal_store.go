@@ -0,0 +1,387 @@
+package points
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// checkpointInterval is how many journal records are appended between
+// checkpoints. A smaller value bounds how much of the log a restart has to
+// replay; a larger value spends less time rewriting the checkpoint file.
+const checkpointInterval = 1000
+
+// journalRecordKind distinguishes the four kinds of record a journalStore
+// appends to its log.
+type journalRecordKind string
+
+const (
+	journalRecordTransaction journalRecordKind = "transaction"
+	journalRecordSpend       journalRecordKind = "spend"
+	journalRecordAudit       journalRecordKind = "audit"
+	journalRecordPosting     journalRecordKind = "posting"
+)
+
+// journalTransaction mirrors Transaction for journal (de)serialization.
+// Transaction.userInitiated is unexported and so is invisible to
+// encoding/json; this type exists purely to carry it across the log and
+// checkpoint files, the same role the explicit column mapping in
+// sql_store.go plays for a SQL-backed Store.
+type journalTransaction struct {
+	ID            int32     `json:"id"`
+	Payer         string    `json:"payer"`
+	Points        int32     `json:"points"`
+	Timestamp     time.Time `json:"timestamp"`
+	UserInitiated bool      `json:"user_initiated"`
+}
+
+func toJournalTransaction(t Transaction) journalTransaction {
+	return journalTransaction{ID: t.ID, Payer: t.Payer, Points: t.Points, Timestamp: t.Timestamp, UserInitiated: t.userInitiated}
+}
+
+func (jt journalTransaction) toTransaction() Transaction {
+	return Transaction{ID: jt.ID, Payer: jt.Payer, Points: jt.Points, Timestamp: jt.Timestamp, userInitiated: jt.UserInitiated}
+}
+
+// journalRecord is a single length-prefixed entry in a journalStore's
+// append-only log file. Only the fields relevant to Kind are populated.
+type journalRecord struct {
+	Kind        journalRecordKind   `json:"kind"`
+	Transaction *journalTransaction `json:"transaction,omitempty"`
+	AuditRecord *AuditRecord        `json:"audit_record,omitempty"`
+	SpendTxID   int32               `json:"spend_tx_id,omitempty"`
+	SpendAmount int32               `json:"spend_amount,omitempty"`
+	Posting     *Posting            `json:"posting,omitempty"`
+}
+
+// checkpoint is the full projection of a journalStore's state as of some
+// point in its log, plus the byte offset in the log that projection
+// reflects. On startup, a journalStore loads the most recent checkpoint and
+// replays only the log records appended after LogOffset, instead of the
+// entire history.
+type checkpoint struct {
+	LogOffset    int64                 `json:"log_offset"`
+	Transactions []journalTransaction  `json:"transactions"`
+	PayerTotals  PayerTotals           `json:"payer_totals"`
+	SpendLog     SpendLog              `json:"spend_log"`
+	AuditLog     map[int32]AuditRecord `json:"audit_log"`
+	SpendJournal []Posting             `json:"spend_journal"`
+}
+
+// journalStore is a Store implementation, inspired by the separation
+// ledger systems like Formance draw between an append-only transaction log
+// and a projected balance snapshot, that writes every Transaction, spend,
+// and audit record to a durable append-only log file as a length-prefixed
+// JSON record, and periodically snapshots its in-memory projection
+// (payerTotals, spendLog, auditLog, and the transaction list) to a
+// checkpoint file so a restart only has to replay the log's tail.
+type journalStore struct {
+	logPath        string
+	checkpointPath string
+	log            *os.File
+
+	logOffset       int64
+	sinceCheckpoint int
+	transactions    []Transaction
+	payerTotals     PayerTotals
+	spendLog        SpendLog
+	auditLog        map[int32]AuditRecord
+	spendJournal    []Posting
+}
+
+// NewJournalStore opens (creating if necessary) a durable journal rooted at
+// dir: dir/journal.log for the append-only record log and
+// dir/checkpoint.json for periodic snapshots of the projected state. It
+// replays the checkpoint, if any, followed by whatever log records were
+// appended after it, before returning.
+func NewJournalStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("NewJournalStore() could not create %v: %w", dir, err)
+	}
+
+	s := &journalStore{
+		logPath:        filepath.Join(dir, "journal.log"),
+		checkpointPath: filepath.Join(dir, "checkpoint.json"),
+		transactions:   []Transaction{},
+		payerTotals:    PayerTotals{},
+		spendLog:       SpendLog{},
+		auditLog:       map[int32]AuditRecord{},
+		spendJournal:   []Posting{},
+	}
+
+	if err := s.loadCheckpoint(); err != nil {
+		return nil, err
+	}
+	if err := s.replayLogTail(); err != nil {
+		return nil, err
+	}
+
+	log, err := os.OpenFile(s.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("NewJournalStore() could not open %v for appending: %w", s.logPath, err)
+	}
+	s.log = log
+
+	return s, nil
+}
+
+// loadCheckpoint restores s's projection and logOffset from
+// s.checkpointPath. A missing checkpoint file simply leaves s at its zero
+// state, to be built up entirely by replayLogTail.
+func (s *journalStore) loadCheckpoint() error {
+	data, err := os.ReadFile(s.checkpointPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("loadCheckpoint() could not read %v: %w", s.checkpointPath, err)
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return fmt.Errorf("loadCheckpoint() could not parse %v: %w", s.checkpointPath, err)
+	}
+
+	s.logOffset = cp.LogOffset
+	s.payerTotals = cp.PayerTotals
+	s.spendLog = cp.SpendLog
+	s.auditLog = cp.AuditLog
+	s.spendJournal = cp.SpendJournal
+	for _, jt := range cp.Transactions {
+		s.transactions = append(s.transactions, jt.toTransaction())
+	}
+	return nil
+}
+
+// replayLogTail applies every record in s.logPath starting at s.logOffset,
+// advancing s.logOffset as it goes, and leaves s.logOffset at the log's
+// current length.
+func (s *journalStore) replayLogTail() error {
+	f, err := os.Open(s.logPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("replayLogTail() could not open %v: %w", s.logPath, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(s.logOffset, io.SeekStart); err != nil {
+		return fmt.Errorf("replayLogTail() could not seek to offset %v in %v: %w", s.logOffset, s.logPath, err)
+	}
+
+	for {
+		rec, n, err := readJournalRecord(f)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("replayLogTail() could not read %v: %w", s.logPath, err)
+		}
+		s.apply(rec)
+		s.logOffset += n
+	}
+	return nil
+}
+
+// apply folds a single journalRecord into s's in-memory projection. It
+// never touches the log file itself, so it's safe to use both when
+// replaying on startup and when appending a freshly-written record.
+func (s *journalStore) apply(rec journalRecord) {
+	switch rec.Kind {
+	case journalRecordTransaction:
+		t := rec.Transaction.toTransaction()
+		s.transactions = append(s.transactions, t)
+		s.payerTotals[t.Payer] += t.Points
+	case journalRecordSpend:
+		s.spendLog[rec.SpendTxID] += rec.SpendAmount
+	case journalRecordAudit:
+		s.auditLog[rec.AuditRecord.TransactionID] = *rec.AuditRecord
+	case journalRecordPosting:
+		s.spendJournal = append(s.spendJournal, *rec.Posting)
+	}
+}
+
+// append writes rec to the log, fsyncs it so the record survives a crash
+// rather than only a process restart, folds it into the in-memory
+// projection via apply, and checkpoints once checkpointInterval records
+// have accumulated since the last one. stateMu's write lock (held by every
+// caller that reaches a journalStore) makes every append single-threaded,
+// so paying for an fsync on each one doesn't need to be made conditional or
+// batched to stay cheap.
+func (s *journalStore) append(rec journalRecord) error {
+	n, err := writeJournalRecord(s.log, rec)
+	if err != nil {
+		return fmt.Errorf("journalStore.append() could not write to %v: %w", s.logPath, err)
+	}
+	if err := s.log.Sync(); err != nil {
+		return fmt.Errorf("journalStore.append() could not fsync %v: %w", s.logPath, err)
+	}
+	s.apply(rec)
+	s.logOffset += n
+	s.sinceCheckpoint++
+
+	if s.sinceCheckpoint >= checkpointInterval {
+		if err := s.checkpoint(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkpoint snapshots s's current projection to s.checkpointPath,
+// recording s.logOffset so a future restart knows to skip everything up to
+// that point in the log. The new checkpoint is written to a temporary file,
+// fsynced, and then renamed into place, so a crash never observes a
+// checkpoint.json that's been truncated or partially written.
+func (s *journalStore) checkpoint() error {
+	cp := checkpoint{
+		LogOffset:    s.logOffset,
+		PayerTotals:  s.payerTotals,
+		SpendLog:     s.spendLog,
+		AuditLog:     s.auditLog,
+		SpendJournal: s.spendJournal,
+	}
+	for _, t := range s.transactions {
+		cp.Transactions = append(cp.Transactions, toJournalTransaction(t))
+	}
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("checkpoint() could not marshal checkpoint: %w", err)
+	}
+	tmpPath := s.checkpointPath + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("checkpoint() could not open %v: %w", tmpPath, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("checkpoint() could not write %v: %w", tmpPath, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("checkpoint() could not fsync %v: %w", tmpPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("checkpoint() could not close %v: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, s.checkpointPath); err != nil {
+		return fmt.Errorf("checkpoint() could not replace %v: %w", s.checkpointPath, err)
+	}
+	s.sinceCheckpoint = 0
+	return nil
+}
+
+// writeJournalRecord marshals rec to JSON and writes it to w prefixed with
+// its length as a big-endian uint32, returning the total number of bytes
+// written.
+func writeJournalRecord(w io.Writer, rec journalRecord) (int64, error) {
+	body, err := json.Marshal(rec)
+	if err != nil {
+		return 0, err
+	}
+	var lengthPrefix [4]byte
+	binary.BigEndian.PutUint32(lengthPrefix[:], uint32(len(body)))
+	if _, err := w.Write(lengthPrefix[:]); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return 0, err
+	}
+	return int64(len(lengthPrefix) + len(body)), nil
+}
+
+// readJournalRecord reads one length-prefixed JSON record written by
+// writeJournalRecord from r, returning it along with the number of bytes
+// consumed. It returns io.EOF (with no other error) once r is exhausted
+// exactly at a record boundary.
+func readJournalRecord(r io.Reader) (journalRecord, int64, error) {
+	var lengthPrefix [4]byte
+	if _, err := io.ReadFull(r, lengthPrefix[:]); err != nil {
+		return journalRecord{}, 0, err
+	}
+	length := binary.BigEndian.Uint32(lengthPrefix[:])
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return journalRecord{}, 0, err
+	}
+
+	var rec journalRecord
+	if err := json.Unmarshal(body, &rec); err != nil {
+		return journalRecord{}, 0, err
+	}
+	return rec, int64(len(lengthPrefix) + len(body)), nil
+}
+
+func (s *journalStore) SaveTransaction(t Transaction) (Transaction, error) {
+	t.ID = transactionUIDs.ID()
+	jt := toJournalTransaction(t)
+	if err := s.append(journalRecord{Kind: journalRecordTransaction, Transaction: &jt}); err != nil {
+		return Transaction{}, err
+	}
+	return t, nil
+}
+
+func (s *journalStore) ListTransactionsByTimestamp() ([]Transaction, error) {
+	result := make([]Transaction, len(s.transactions))
+	copy(result, s.transactions)
+	sort.Sort(ByTimestamp(result))
+	return result, nil
+}
+
+func (s *journalStore) GetPayerTotals() (PayerTotals, error) {
+	return s.payerTotals, nil
+}
+
+func (s *journalStore) GetSpendLog() (SpendLog, error) {
+	return s.spendLog, nil
+}
+
+func (s *journalStore) RecordSpend(txID int32, amount int32) error {
+	return s.append(journalRecord{Kind: journalRecordSpend, SpendTxID: txID, SpendAmount: amount})
+}
+
+func (s *journalStore) AppendPosting(p Posting) error {
+	return s.append(journalRecord{Kind: journalRecordPosting, Posting: &p})
+}
+
+func (s *journalStore) ListPostings() ([]Posting, error) {
+	result := make([]Posting, len(s.spendJournal))
+	copy(result, s.spendJournal)
+	return result, nil
+}
+
+func (s *journalStore) RecordAudit(rec AuditRecord) error {
+	return s.append(journalRecord{Kind: journalRecordAudit, AuditRecord: &rec})
+}
+
+func (s *journalStore) GetAuditTrail(txID int32) (AuditRecord, bool, error) {
+	rec, ok := s.auditLog[txID]
+	return rec, ok, nil
+}
+
+func (s *journalStore) Reset() {
+	s.transactions = []Transaction{}
+	s.payerTotals = PayerTotals{}
+	s.spendLog = SpendLog{}
+	s.auditLog = map[int32]AuditRecord{}
+	s.spendJournal = []Posting{}
+	s.logOffset = 0
+	s.sinceCheckpoint = 0
+
+	s.log.Close()
+	os.Remove(s.logPath)
+	os.Remove(s.checkpointPath)
+	log, err := os.OpenFile(s.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		panic(fmt.Errorf("journalStore.Reset() could not recreate %v: %w", s.logPath, err))
+	}
+	s.log = log
+}
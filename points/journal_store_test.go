@@ -0,0 +1,127 @@
+package points
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJournalStoreSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := NewJournalStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := s.SaveTransaction(Transaction{Payer: "DANNON", Points: 1000, Timestamp: mustParseTime(t, "2020-11-02T14:00:00Z")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordSpend(saved.ID, 300); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordAudit(AuditRecord{TransactionID: saved.ID, Reason: "test note"}); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewJournalStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactions, err := reopened.ListTransactionsByTimestamp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 1 || transactions[0].Payer != "DANNON" || transactions[0].Points != 1000 {
+		t.Fatalf("reopened journal lost the saved transaction: got %v", transactions)
+	}
+
+	spendLog, err := reopened.GetSpendLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := spendLog[saved.ID], int32(300); got != want {
+		t.Errorf("reopened journal lost the spend record: got %v expected %v", got, want)
+	}
+
+	audit, ok, err := reopened.GetAuditTrail(saved.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || audit.Reason != "test note" {
+		t.Errorf("reopened journal lost the audit record: got %v, found %v", audit, ok)
+	}
+}
+
+func TestJournalStoreCheckpointsAfterInterval(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := NewJournalStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	js := s.(*journalStore)
+	for i := 0; i < checkpointInterval+1; i++ {
+		if _, err := s.SaveTransaction(Transaction{Payer: "DANNON", Points: 1, Timestamp: mustParseTime(t, "2020-11-02T14:00:00Z")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if js.sinceCheckpoint != 1 {
+		t.Errorf("expected a checkpoint to have been written after %v records: sinceCheckpoint = %v", checkpointInterval+1, js.sinceCheckpoint)
+	}
+
+	reopened, err := NewJournalStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactions, err := reopened.ListTransactionsByTimestamp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(transactions), checkpointInterval+1; got != want {
+		t.Errorf("reopened journal replayed the wrong number of transactions: got %v expected %v", got, want)
+	}
+}
+
+func TestJournalStoreReset(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := NewJournalStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SaveTransaction(Transaction{Payer: "DANNON", Points: 1000, Timestamp: mustParseTime(t, "2020-11-02T14:00:00Z")}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Reset()
+
+	transactions, err := s.ListTransactionsByTimestamp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 0 {
+		t.Errorf("Reset() left transactions behind: got %v", transactions)
+	}
+
+	reopened, err := NewJournalStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactions, err = reopened.ListTransactionsByTimestamp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 0 {
+		t.Errorf("Reset() didn't clear the durable journal: reopening replayed %v", transactions)
+	}
+}
+
+func mustParseTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
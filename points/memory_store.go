@@ -0,0 +1,81 @@
+package points
+
+import "sort"
+
+// memoryStore is a Store implementation that keeps all state in process
+// memory. It is the default Store and is equivalent to the package-level
+// globals this package used before Store was introduced.
+type memoryStore struct {
+	allTransactions   []Transaction
+	payerTotals       PayerTotals
+	spentTransactions SpendLog
+	auditLog          map[int32]AuditRecord
+	spendJournal      []Posting
+}
+
+// NewMemoryStore constructs an empty in-memory Store.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		allTransactions:   []Transaction{},
+		payerTotals:       PayerTotals{},
+		spentTransactions: SpendLog{},
+		auditLog:          map[int32]AuditRecord{},
+		spendJournal:      []Posting{},
+	}
+}
+
+func (m *memoryStore) SaveTransaction(t Transaction) (Transaction, error) {
+	t.ID = transactionUIDs.ID()
+	m.allTransactions = append(m.allTransactions, t)
+	m.payerTotals[t.Payer] += t.Points
+	return t, nil
+}
+
+func (m *memoryStore) ListTransactionsByTimestamp() ([]Transaction, error) {
+	result := make([]Transaction, len(m.allTransactions))
+	copy(result, m.allTransactions)
+	sort.Sort(ByTimestamp(result))
+	return result, nil
+}
+
+func (m *memoryStore) GetPayerTotals() (PayerTotals, error) {
+	return m.payerTotals, nil
+}
+
+func (m *memoryStore) GetSpendLog() (SpendLog, error) {
+	return m.spentTransactions, nil
+}
+
+func (m *memoryStore) RecordSpend(txID int32, amount int32) error {
+	m.spentTransactions[txID] += amount
+	return nil
+}
+
+func (m *memoryStore) AppendPosting(p Posting) error {
+	m.spendJournal = append(m.spendJournal, p)
+	return nil
+}
+
+func (m *memoryStore) ListPostings() ([]Posting, error) {
+	result := make([]Posting, len(m.spendJournal))
+	copy(result, m.spendJournal)
+	return result, nil
+}
+
+func (m *memoryStore) RecordAudit(rec AuditRecord) error {
+	m.auditLog[rec.TransactionID] = rec
+	return nil
+}
+
+func (m *memoryStore) GetAuditTrail(txID int32) (AuditRecord, bool, error) {
+	rec, ok := m.auditLog[txID]
+	return rec, ok, nil
+}
+
+func (m *memoryStore) Reset() {
+	m.allTransactions = []Transaction{}
+	m.payerTotals = PayerTotals{}
+	m.spentTransactions = SpendLog{}
+	m.auditLog = map[int32]AuditRecord{}
+	m.spendJournal = []Posting{}
+}
@@ -0,0 +1,96 @@
+package points
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migration describes a single forward-only schema change, applied in the
+// order it appears in the migrations slice.
+type migration struct {
+	version int
+	stmt    string
+}
+
+// migrations is the ordered set of schema changes applied to a SQL-backed
+// Store. Add new entries to the end of this slice; never edit or remove an
+// existing entry once it has shipped, since deployed databases record which
+// versions they've already applied in schema_migrations.
+var migrations = []migration{
+	{
+		version: 1,
+		stmt: `CREATE TABLE IF NOT EXISTS transactions (
+			id SERIAL PRIMARY KEY,
+			payer TEXT NOT NULL,
+			points INTEGER NOT NULL,
+			timestamp TIMESTAMPTZ NOT NULL,
+			user_initiated BOOLEAN NOT NULL DEFAULT FALSE
+		)`,
+	},
+	{
+		version: 2,
+		stmt: `CREATE TABLE IF NOT EXISTS spend_log (
+			transaction_id INTEGER PRIMARY KEY REFERENCES transactions(id),
+			spent_points INTEGER NOT NULL
+		)`,
+	},
+	{
+		version: 3,
+		stmt: `CREATE TABLE IF NOT EXISTS audit_log (
+			transaction_id INTEGER PRIMARY KEY REFERENCES transactions(id),
+			created_at TIMESTAMPTZ NOT NULL,
+			reversed_by INTEGER REFERENCES transactions(id),
+			reason TEXT NOT NULL DEFAULT ''
+		)`,
+	},
+	{
+		version: 4,
+		stmt: `CREATE TABLE IF NOT EXISTS spend_journal (
+			id SERIAL PRIMARY KEY,
+			source_tx_id INTEGER NOT NULL REFERENCES transactions(id),
+			payer TEXT NOT NULL,
+			amount INTEGER NOT NULL,
+			timestamp TIMESTAMPTZ NOT NULL
+		)`,
+	},
+	{
+		version: 5,
+		stmt:    `ALTER TABLE spend_journal ADD COLUMN compensating_tx_id INTEGER REFERENCES transactions(id)`,
+	},
+}
+
+// runMigrations ensures a schema_migrations table exists, then applies any
+// migration whose version has not yet been recorded there. It is safe to
+// call on every startup.
+func runMigrations(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("runMigrations() could not create schema_migrations table: %w", err)
+	}
+
+	applied := map[int]bool{}
+	rows, err := db.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("runMigrations() could not read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return fmt.Errorf("runMigrations() could not scan schema_migrations row: %w", err)
+		}
+		applied[v] = true
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+		if _, err := db.Exec(m.stmt); err != nil {
+			return fmt.Errorf("runMigrations() migration %d failed: %w", m.version, err)
+		}
+		if _, err := db.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, m.version); err != nil {
+			return fmt.Errorf("runMigrations() could not record migration %d: %w", m.version, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package points
+
+// SimulateSpend computes how a spend of the given number of points would be
+// split across payers using the same oldest-first, non-negative-balance
+// algorithm as SpendPoints, without mutating any state. It's the engine
+// behind the "dry run" /spend/simulate endpoint: callers can preview a
+// spend's effect and decide whether to actually perform it via SpendPoints.
+func SimulateSpend(desired int32) ([]PayerBalance, error) {
+	stateMu.RLock()
+	transactions, err := defaultStore.ListTransactionsByTimestamp()
+	if err != nil {
+		stateMu.RUnlock()
+		return nil, E("points.SimulateSpend", KindStorage, err)
+	}
+	spendLog, err := defaultStore.GetSpendLog()
+	if err != nil {
+		stateMu.RUnlock()
+		return nil, E("points.SimulateSpend", KindStorage, err)
+	}
+	payerTotals, err := defaultStore.GetPayerTotals()
+	if err != nil {
+		stateMu.RUnlock()
+		return nil, E("points.SimulateSpend", KindStorage, err)
+	}
+	stateMu.RUnlock()
+
+	// buildSpendIndex and spendFromIndex only ever read and mutate the
+	// nodes they allocate from these snapshots, so this never touches the
+	// Store.
+	index := buildSpendIndex(transactions, spendLog, payerTotals)
+	spentPayerPoints := spendFromIndex(index, desired, nil)
+	return spentPayerPoints.ToPayerBalances(), nil
+}
@@ -0,0 +1,78 @@
+package points
+
+import "testing"
+
+func TestSimulateSpendLeavesPayerTotalsUnchanged(t *testing.T) {
+	ResetTransactions()
+
+	t1, _ := NewTransaction("DANNON", 1000, "2020-11-02T14:00:00Z")
+	t1.Save()
+	t2, _ := NewTransaction("UNILEVER", 200, "2020-10-31T11:00:00Z")
+	t2.Save()
+	t3, _ := NewTransaction("MILLER COORS", 10000, "2020-11-01T14:00:00Z")
+	t3.Save()
+
+	before, _ := GetPayerTotals()
+	beforeCopy := PayerTotals{}
+	for k, v := range before {
+		beforeCopy[k] = v
+	}
+
+	result, err := SimulateSpend(5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) == 0 {
+		t.Fatal("expected simulate to project at least one payer balance")
+	}
+
+	after, _ := GetPayerTotals()
+	for payer, total := range beforeCopy {
+		if after[payer] != total {
+			t.Errorf("SimulateSpend mutated payer totals for %v: got %v expected %v", payer, after[payer], total)
+		}
+	}
+}
+
+func TestSimulateSpendMatchesActualSpend(t *testing.T) {
+	ResetTransactions()
+
+	t1, _ := NewTransaction("DANNON", 1000, "2020-11-02T14:00:00Z")
+	t1.Save()
+	t2, _ := NewTransaction("UNILEVER", 200, "2020-10-31T11:00:00Z")
+	t2.Save()
+
+	simulated, err := SimulateSpend(500)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactions, _ := GetTransactions()
+	spentPayerPoints := PayerTotals{}
+	var remaining int32 = 500
+	for _, tr := range transactions {
+		if remaining <= 0 {
+			break
+		}
+		spent, spendErr := tr.SpendPoints(remaining)
+		if spendErr != nil {
+			continue
+		}
+		spentPayerPoints[tr.Payer] -= spent
+		remaining -= spent
+	}
+	actual := spentPayerPoints.ToPayerBalances()
+
+	if len(simulated) != len(actual) {
+		t.Fatalf("simulate and actual spend disagree on payer count: got %v expected %v", simulated, actual)
+	}
+	actualByPayer := map[string]int32{}
+	for _, pb := range actual {
+		actualByPayer[pb.Payer] = pb.Points
+	}
+	for _, pb := range simulated {
+		if actualByPayer[pb.Payer] != pb.Points {
+			t.Errorf("simulate disagreed with actual spend for %v: got %v expected %v", pb.Payer, pb.Points, actualByPayer[pb.Payer])
+		}
+	}
+}
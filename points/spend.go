@@ -16,12 +16,9 @@ type SpendLog map[int32]int32
 
 // GetSpentTransactions returns a map of Transaction ids to int32 representing how much of each Transaction has been spent.
 func GetSpentTransactions() (SpendLog, error) {
-	return spentTransactions, nil
-}
-
-// SetSpentTransactions updates the SpendLog for a given transaction.
-func SetSpentTransactions(t Transaction, newAmount int32) {
-	spentTransactions[t.id] = newAmount
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	return defaultStore.GetSpendLog()
 }
 
 // TODO: implement a function that spends points from across all transactions
@@ -31,22 +28,34 @@ func SetSpentTransactions(t Transaction, newAmount int32) {
 // As long as it will not cause a payer's balance to go below zero, a new transaction will be added to the log indicating how many points were spent.
 // The number of points actually spent will be returned.
 // Consider this a placeholder for a series of database queries.
+//
+// The whole check-then-act sequence below runs under stateMu's write lock so
+// that two concurrent spends against the same payer can't both observe a
+// balance that's high enough to cover their request and then both debit it,
+// driving the payer negative.
 func (t *Transaction) SpendPoints(points int32) (int32, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
 	var actualSpent int32 = 0
 	toSpend := points
 	available := t.Points
 
 	// If this transaction represents points spent by the user, they cannot be spent.
 	if t.userInitiated {
-		awdErr := errors.New("SpendPoints() this transaction refers to spent points. You cannot spend points that have already been spent")
-		return actualSpent, awdErr
+		awdErr := errors.New("this transaction refers to spent points. You cannot spend points that have already been spent")
+		return actualSpent, E("points.SpendPoints", KindConflict, awdErr, t.Payer, t.ID)
+	}
+	spendLog, err := defaultStore.GetSpendLog()
+	if err != nil {
+		return actualSpent, E("points.SpendPoints", KindStorage, err, t.Payer, t.ID)
 	}
 	// Check to see how many points from this transaction have already been used. Update the amount of points available from it.
-	if spent, ok := spentTransactions[t.id]; ok {
+	if spent, ok := spendLog[t.ID]; ok {
 		// Don't continue if all of these points have already been spent.
 		if spent == t.Points {
-			spentErr := fmt.Errorf("SpendPoints() these points have already been spent. original points: %v, spent: %v", t.Points, spent)
-			return actualSpent, spentErr
+			spentErr := fmt.Errorf("these points have already been spent. original points: %v, spent: %v", t.Points, spent)
+			return actualSpent, E("points.SpendPoints", KindConflict, spentErr, t.Payer, t.ID)
 		}
 		available -= spent
 	}
@@ -54,19 +63,87 @@ func (t *Transaction) SpendPoints(points int32) (int32, error) {
 	if available < toSpend {
 		toSpend = available
 	}
+	payerTotals, err := defaultStore.GetPayerTotals()
+	if err != nil {
+		return actualSpent, E("points.SpendPoints", KindStorage, err, t.Payer, t.ID)
+	}
 	// If spending these points would bring this payer's balance below zero, don't spend them and return 0 as the number of points spent.
 	if payerTotals[t.Payer]-toSpend < 0 {
-		negErr := fmt.Errorf("SpendPoints() cannot spend points if it would cause a payer's balance to go below zero. available: %v, requested spend: %v", payerTotals[t.Payer], toSpend)
-		return actualSpent, negErr
+		negErr := fmt.Errorf("cannot spend points if it would cause a payer's balance to go below zero. available: %v, requested spend: %v", payerTotals[t.Payer], toSpend)
+		return actualSpent, E("points.SpendPoints", KindInsufficientFunds, negErr, t.Payer, t.ID)
 	}
 	// Create a new Transaction to register these spent points.
 	// Note that these points are being spent by the user, not awarded by a payer.
 	newT := Transaction{Payer: t.Payer, Points: -toSpend, Timestamp: time.Now(), userInitiated: true}
-	if saveErr := newT.Save(); saveErr != nil {
+	if saveErr := newT.saveLocked(); saveErr != nil {
 		// If this new transaction is invalid, simply return 0 - the amount spent from the original transaction.
 		return actualSpent, saveErr
 	}
 	actualSpent = toSpend
-	spentTransactions[t.id] += actualSpent
+	if recordErr := defaultStore.RecordSpend(t.ID, actualSpent); recordErr != nil {
+		return actualSpent, E("points.SpendPoints", KindStorage, recordErr, t.Payer, t.ID)
+	}
+	posting := Posting{SourceTxID: t.ID, Payer: t.Payer, Amount: actualSpent, Timestamp: newT.Timestamp, CompensatingTxID: newT.ID}
+	if postingErr := defaultStore.AppendPosting(posting); postingErr != nil {
+		return actualSpent, E("points.SpendPoints", KindStorage, postingErr, t.Payer, t.ID)
+	}
 	return actualSpent, nil
 }
+
+// SpendAcrossPayers spends amount points across the available Transactions,
+// oldest first, without letting any payer's balance go below zero. It is the
+// context-free counterpart of SpendPointsCtx, which is what
+// SpendPointsHandler calls.
+//
+// Rather than scanning a sorted slice linearly - which revisits
+// Transactions from payers already at zero - it builds a heap-of-heaps
+// index (buildSpendIndex) and pops from it via spendFromIndex, so each of
+// the k Transactions a spend actually touches costs O(log n). The index is
+// not kept between calls: every call lists all n Transactions from the
+// Store (which the in-memory and journal Stores sort on each call) and
+// rebuilds the index, so a spend as a whole still costs at least O(n).
+//
+// The response is the number of points debited from each payer, as a
+// PayerTotals of negative amounts.
+func SpendAcrossPayers(amount int32) (PayerTotals, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	transactions, err := defaultStore.ListTransactionsByTimestamp()
+	if err != nil {
+		return nil, E("points.SpendAcrossPayers", KindStorage, err)
+	}
+	spendLog, err := defaultStore.GetSpendLog()
+	if err != nil {
+		return nil, E("points.SpendAcrossPayers", KindStorage, err)
+	}
+	payerTotals, err := defaultStore.GetPayerTotals()
+	if err != nil {
+		return nil, E("points.SpendAcrossPayers", KindStorage, err)
+	}
+
+	index := buildSpendIndex(transactions, spendLog, payerTotals)
+	var spendErr error
+	spentPayerPoints := spendFromIndex(index, amount, func(payer string, txID int32, spent int32) {
+		if spendErr != nil {
+			return
+		}
+		if err := defaultStore.RecordSpend(txID, spent); err != nil {
+			spendErr = E("points.SpendAcrossPayers", KindStorage, err, payer, txID)
+			return
+		}
+		compensating := Transaction{Payer: payer, Points: -spent, Timestamp: time.Now(), userInitiated: true}
+		if err := compensating.saveLocked(); err != nil {
+			spendErr = err
+			return
+		}
+		posting := Posting{SourceTxID: txID, Payer: payer, Amount: spent, Timestamp: compensating.Timestamp, CompensatingTxID: compensating.ID}
+		if err := defaultStore.AppendPosting(posting); err != nil {
+			spendErr = E("points.SpendAcrossPayers", KindStorage, err, payer, txID)
+		}
+	})
+	if spendErr != nil {
+		return nil, spendErr
+	}
+	return spentPayerPoints, nil
+}
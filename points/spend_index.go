@@ -0,0 +1,178 @@
+package points
+
+import (
+	"container/heap"
+	"context"
+	"time"
+)
+
+// remainingNode tracks one Transaction's contribution to a payer's spend
+// index: how many points are still unspent from it, and when it was
+// originally awarded (so the index can always offer up the oldest one).
+type remainingNode struct {
+	id        int32
+	payer     string
+	timestamp time.Time
+	remaining int32
+}
+
+// payerHeap is a min-heap of a single payer's remainingNodes, ordered
+// oldest-timestamp-first.
+type payerHeap []*remainingNode
+
+func (h payerHeap) Len() int           { return len(h) }
+func (h payerHeap) Less(i, j int) bool { return h[i].timestamp.Before(h[j].timestamp) }
+func (h payerHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *payerHeap) Push(x interface{}) { *h = append(*h, x.(*remainingNode)) }
+
+func (h *payerHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	node := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return node
+}
+
+// payerBucket is one payer's entry in the top-level heap-of-heaps: its own
+// min-heap of unspent Transactions, plus the running balance checked before
+// spending from any one of them.
+type payerBucket struct {
+	payer   string
+	balance int32
+	unspent *payerHeap
+}
+
+// bucketHeap is the top-level heap-of-heaps. It orders payerBuckets by the
+// timestamp of each bucket's own oldest unspent Transaction, so the bucket
+// holding the globally-oldest unspent Transaction is always at the root.
+// Two buckets tied on that timestamp are broken by payer name, ascending,
+// so the pop order is reproducible - buildSpendIndex otherwise assembles
+// this heap from a map, whose iteration order Go deliberately randomizes.
+type bucketHeap []*payerBucket
+
+func (h bucketHeap) Len() int { return len(h) }
+func (h bucketHeap) Less(i, j int) bool {
+	ti, tj := (*h[i].unspent)[0].timestamp, (*h[j].unspent)[0].timestamp
+	if !ti.Equal(tj) {
+		return ti.Before(tj)
+	}
+	return h[i].payer < h[j].payer
+}
+func (h bucketHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *bucketHeap) Push(x interface{}) { *h = append(*h, x.(*payerBucket)) }
+
+func (h *bucketHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	bucket := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return bucket
+}
+
+// buildSpendIndex assembles the heap-of-heaps described above from a
+// snapshot of Transactions, spend amounts, and payer balances: one min-heap
+// per payer of that payer's unspent Transactions, and a top-level heap
+// ordering those payer buckets by their oldest unspent Transaction.
+// user-initiated Transactions, and Transactions that have already been
+// spent in full, are excluded up front - the same two cases a single
+// Transaction.SpendPoints() call would refuse on its own.
+//
+// Building the index is still O(n), but every pop and partial update that
+// follows (in spendFromIndex) is O(log n), and a spend only touches as many
+// Transactions as it actually needs - unlike sorting every Transaction and
+// scanning it linearly on every request.
+func buildSpendIndex(transactions []Transaction, spendLog SpendLog, payerTotals PayerTotals) *bucketHeap {
+	byPayer := map[string]*payerHeap{}
+	for _, t := range transactions {
+		if t.userInitiated {
+			continue
+		}
+		remaining := t.Points - spendLog[t.ID]
+		if remaining == 0 {
+			continue
+		}
+		h, ok := byPayer[t.Payer]
+		if !ok {
+			h = &payerHeap{}
+			byPayer[t.Payer] = h
+		}
+		*h = append(*h, &remainingNode{id: t.ID, payer: t.Payer, timestamp: t.Timestamp, remaining: remaining})
+	}
+
+	top := &bucketHeap{}
+	for payer, h := range byPayer {
+		heap.Init(h)
+		*top = append(*top, &payerBucket{payer: payer, balance: payerTotals[payer], unspent: h})
+	}
+	heap.Init(top)
+	return top
+}
+
+// spendFromIndex pops the globally-oldest unspent Transaction from index,
+// repeatedly, until amount has been fully spent or index is exhausted. For
+// each Transaction it takes as much as it can - bounded by how much of that
+// Transaction remains and by its payer's current balance - exactly as a
+// direct Transaction.SpendPoints(remainingToSpend) call would, including
+// its handling of Transactions with a negative Points value: "spending"
+// from one of those brings the payer's balance up rather than down, so it
+// is debited against bucket.balance like any other Transaction rather than
+// being treated as unspendable. A Transaction that would bring its payer's
+// balance below zero is skipped for good, same as SpendPoints() returning
+// an error for it. onSpend is called once per Transaction actually spent
+// from, so callers can persist the result (SpendAcrossPayers) or simply
+// observe it (SimulateSpend).
+func spendFromIndex(index *bucketHeap, amount int32, onSpend func(payer string, txID int32, spent int32)) PayerTotals {
+	spent, _ := spendFromIndexCtx(context.Background(), index, amount, onSpend)
+	return spent
+}
+
+// spendFromIndexCtx behaves like spendFromIndex, but checks ctx for
+// cancellation before popping each Transaction from index, stopping the
+// spend as soon as ctx is done rather than running it to completion
+// regardless of a client disconnect or deadline. It returns the PayerTotals
+// actually debited before stopping, and ctx.Err() if that's why it stopped.
+func spendFromIndexCtx(ctx context.Context, index *bucketHeap, amount int32, onSpend func(payer string, txID int32, spent int32)) (PayerTotals, error) {
+	spentPayerPoints := PayerTotals{}
+	remainingToSpend := amount
+
+	for remainingToSpend > 0 && index.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return spentPayerPoints, err
+		}
+
+		bucket := (*index)[0]
+		node := (*bucket.unspent)[0]
+
+		toSpend := remainingToSpend
+		if node.remaining < toSpend {
+			toSpend = node.remaining
+		}
+
+		if bucket.balance-toSpend < 0 {
+			heap.Pop(bucket.unspent)
+		} else {
+			node.remaining -= toSpend
+			bucket.balance -= toSpend
+			spentPayerPoints[bucket.payer] -= toSpend
+			remainingToSpend -= toSpend
+			if onSpend != nil {
+				onSpend(bucket.payer, node.id, toSpend)
+			}
+			if node.remaining == 0 {
+				heap.Pop(bucket.unspent)
+			}
+		}
+
+		if bucket.unspent.Len() == 0 {
+			heap.Pop(index)
+		} else {
+			heap.Fix(index, 0)
+		}
+	}
+
+	return spentPayerPoints, nil
+}
@@ -0,0 +1,94 @@
+package points
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+// benchTransactions returns n synthetic, already-awarded Transactions
+// spread evenly across a handful of payers with strictly increasing
+// timestamps, for comparing the old linear scan against buildSpendIndex at
+// scale. None of them have been spent from yet.
+func benchTransactions(n int) ([]Transaction, SpendLog, PayerTotals) {
+	payers := []string{"DANNON", "UNILEVER", "MILLER COORS", "NATIONAL"}
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	transactions := make([]Transaction, n)
+	payerTotals := PayerTotals{}
+	for i := 0; i < n; i++ {
+		payer := payers[i%len(payers)]
+		transactions[i] = Transaction{
+			ID:        int32(i),
+			Payer:     payer,
+			Points:    100,
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+		}
+		payerTotals[payer] += 100
+	}
+	return transactions, SpendLog{}, payerTotals
+}
+
+// spendLinear reproduces the pre-buildSpendIndex SpendPointsHandler
+// algorithm - sort every Transaction by timestamp, then scan the sorted
+// slice linearly - purely for comparison in the benchmarks below.
+func spendLinear(transactions []Transaction, spendLog SpendLog, payerTotals PayerTotals, amount int32) PayerTotals {
+	sorted := make([]Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.Sort(ByTimestamp(sorted))
+
+	remainingSpent := SpendLog{}
+	for id, spent := range spendLog {
+		remainingSpent[id] = spent
+	}
+	remainingTotals := PayerTotals{}
+	for payer, total := range payerTotals {
+		remainingTotals[payer] = total
+	}
+
+	spent := PayerTotals{}
+	remainingToSpend := amount
+	for _, t := range sorted {
+		if remainingToSpend <= 0 {
+			break
+		}
+		available := t.Points - remainingSpent[t.ID]
+		toSpend := remainingToSpend
+		if available < toSpend {
+			toSpend = available
+		}
+		if toSpend <= 0 || remainingTotals[t.Payer]-toSpend < 0 {
+			continue
+		}
+		remainingTotals[t.Payer] -= toSpend
+		remainingSpent[t.ID] += toSpend
+		spent[t.Payer] -= toSpend
+		remainingToSpend -= toSpend
+	}
+	return spent
+}
+
+func benchmarkSpendLinear(b *testing.B, n int) {
+	transactions, spendLog, payerTotals := benchTransactions(n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		spendLinear(transactions, spendLog, payerTotals, 250)
+	}
+}
+
+func benchmarkSpendIndex(b *testing.B, n int) {
+	transactions, spendLog, payerTotals := benchTransactions(n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index := buildSpendIndex(transactions, spendLog, payerTotals)
+		spendFromIndex(index, 250, nil)
+	}
+}
+
+func BenchmarkSpendLinear10k(b *testing.B)  { benchmarkSpendLinear(b, 10_000) }
+func BenchmarkSpendLinear100k(b *testing.B) { benchmarkSpendLinear(b, 100_000) }
+func BenchmarkSpendLinear1M(b *testing.B)   { benchmarkSpendLinear(b, 1_000_000) }
+
+func BenchmarkSpendIndex10k(b *testing.B)  { benchmarkSpendIndex(b, 10_000) }
+func BenchmarkSpendIndex100k(b *testing.B) { benchmarkSpendIndex(b, 100_000) }
+func BenchmarkSpendIndex1M(b *testing.B)   { benchmarkSpendIndex(b, 1_000_000) }
@@ -0,0 +1,86 @@
+package points
+
+import (
+	"fmt"
+	"time"
+)
+
+// Posting is a single immutable double-entry movement recording that amount
+// points were taken from the Transaction identified by SourceTxID on behalf
+// of Payer. A spend that draws from several Transactions produces one
+// Posting per Transaction it touches. CompensatingTxID is the id of the
+// userInitiated Transaction this Posting was recorded alongside (e.g. the
+// one Transaction.SpendPoints or SpendAcrossPayers creates to represent the
+// debit) - not to be confused with SourceTxID, the originally-awarded
+// Transaction the points came from. ReverseTransaction uses it to find and
+// offset the Postings a reversed spend produced.
+type Posting struct {
+	SourceTxID       int32     `json:"source_tx_id"`
+	Payer            string    `json:"payer"`
+	Amount           int32     `json:"amount"`
+	Timestamp        time.Time `json:"timestamp"`
+	CompensatingTxID int32     `json:"compensating_tx_id,omitempty"`
+}
+
+// GetSpendJournal returns every Posting ever recorded, oldest first.
+func GetSpendJournal() ([]Posting, error) {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	return defaultStore.ListPostings()
+}
+
+// Verify recomputes each payer's balance from the payer-awarded (not
+// userInitiated) Transactions and the spend journal's Postings, and compares
+// it against the Store's own GetPayerTotals(). It returns an error
+// describing every payer whose live balance has drifted from what its
+// awards and postings actually imply - the Postings are meant to be an
+// independent, append-only record of every spend, so any disagreement
+// points at a bug in how a spend updated PayerTotals rather than in the
+// journal itself.
+func Verify() error {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+
+	transactions, err := defaultStore.ListTransactionsByTimestamp()
+	if err != nil {
+		return err
+	}
+	postings, err := defaultStore.ListPostings()
+	if err != nil {
+		return err
+	}
+	live, err := defaultStore.GetPayerTotals()
+	if err != nil {
+		return err
+	}
+
+	recomputed := PayerTotals{}
+	for _, t := range transactions {
+		if t.userInitiated {
+			continue
+		}
+		recomputed[t.Payer] += t.Points
+	}
+	for _, p := range postings {
+		recomputed[p.Payer] -= p.Amount
+	}
+
+	payers := map[string]bool{}
+	for payer := range recomputed {
+		payers[payer] = true
+	}
+	for payer := range live {
+		payers[payer] = true
+	}
+
+	var drifted []string
+	for payer := range payers {
+		if recomputed[payer] != live[payer] {
+			drifted = append(drifted, fmt.Sprintf("%v: live=%v recomputed=%v", payer, live[payer], recomputed[payer]))
+		}
+	}
+	if len(drifted) > 0 {
+		return fmt.Errorf("Verify() payerTotals have drifted from the spend journal: %v", drifted)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package points
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// sqlStore is a Store implementation backed by a SQL database reachable
+// through database/sql. It expects the caller to have already opened db
+// with whichever driver is appropriate (e.g. lib/pq, pgx, mattn/go-sqlite3)
+// via a blank driver import in main; sqlStore itself is driver-agnostic.
+type sqlStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore wraps db as a Store, running any pending schema migrations
+// (tracked in a schema_migrations table) before returning.
+func NewSQLStore(db *sql.DB) (Store, error) {
+	if err := runMigrations(db); err != nil {
+		return nil, err
+	}
+	return &sqlStore{db: db}, nil
+}
+
+func (s *sqlStore) SaveTransaction(t Transaction) (Transaction, error) {
+	row := s.db.QueryRow(
+		`INSERT INTO transactions (payer, points, timestamp, user_initiated) VALUES ($1, $2, $3, $4) RETURNING id`,
+		t.Payer, t.Points, t.Timestamp, t.userInitiated,
+	)
+	if err := row.Scan(&t.ID); err != nil {
+		return Transaction{}, fmt.Errorf("sqlStore.SaveTransaction() %w", err)
+	}
+	return t, nil
+}
+
+func (s *sqlStore) ListTransactionsByTimestamp() ([]Transaction, error) {
+	rows, err := s.db.Query(`SELECT id, payer, points, timestamp, user_initiated FROM transactions ORDER BY timestamp ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("sqlStore.ListTransactionsByTimestamp() %w", err)
+	}
+	defer rows.Close()
+
+	result := []Transaction{}
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.ID, &t.Payer, &t.Points, &t.Timestamp, &t.userInitiated); err != nil {
+			return nil, fmt.Errorf("sqlStore.ListTransactionsByTimestamp() %w", err)
+		}
+		result = append(result, t)
+	}
+	return result, rows.Err()
+}
+
+func (s *sqlStore) GetPayerTotals() (PayerTotals, error) {
+	rows, err := s.db.Query(`SELECT payer, SUM(points) FROM transactions GROUP BY payer`)
+	if err != nil {
+		return nil, fmt.Errorf("sqlStore.GetPayerTotals() %w", err)
+	}
+	defer rows.Close()
+
+	totals := PayerTotals{}
+	for rows.Next() {
+		var payer string
+		var total int32
+		if err := rows.Scan(&payer, &total); err != nil {
+			return nil, fmt.Errorf("sqlStore.GetPayerTotals() %w", err)
+		}
+		totals[payer] = total
+	}
+	return totals, rows.Err()
+}
+
+func (s *sqlStore) GetSpendLog() (SpendLog, error) {
+	rows, err := s.db.Query(`SELECT transaction_id, spent_points FROM spend_log`)
+	if err != nil {
+		return nil, fmt.Errorf("sqlStore.GetSpendLog() %w", err)
+	}
+	defer rows.Close()
+
+	log := SpendLog{}
+	for rows.Next() {
+		var txID int32
+		var spent int32
+		if err := rows.Scan(&txID, &spent); err != nil {
+			return nil, fmt.Errorf("sqlStore.GetSpendLog() %w", err)
+		}
+		log[txID] = spent
+	}
+	return log, rows.Err()
+}
+
+func (s *sqlStore) RecordSpend(txID int32, amount int32) error {
+	_, err := s.db.Exec(
+		`INSERT INTO spend_log (transaction_id, spent_points) VALUES ($1, $2)
+		 ON CONFLICT (transaction_id) DO UPDATE SET spent_points = spend_log.spent_points + EXCLUDED.spent_points`,
+		txID, amount,
+	)
+	if err != nil {
+		return fmt.Errorf("sqlStore.RecordSpend() %w", err)
+	}
+	return nil
+}
+
+func (s *sqlStore) AppendPosting(p Posting) error {
+	var compensatingTxID sql.NullInt32
+	if p.CompensatingTxID != 0 {
+		compensatingTxID = sql.NullInt32{Int32: p.CompensatingTxID, Valid: true}
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO spend_journal (source_tx_id, payer, amount, timestamp, compensating_tx_id) VALUES ($1, $2, $3, $4, $5)`,
+		p.SourceTxID, p.Payer, p.Amount, p.Timestamp, compensatingTxID,
+	)
+	if err != nil {
+		return fmt.Errorf("sqlStore.AppendPosting() %w", err)
+	}
+	return nil
+}
+
+func (s *sqlStore) ListPostings() ([]Posting, error) {
+	rows, err := s.db.Query(`SELECT source_tx_id, payer, amount, timestamp, compensating_tx_id FROM spend_journal ORDER BY id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("sqlStore.ListPostings() %w", err)
+	}
+	defer rows.Close()
+
+	result := []Posting{}
+	for rows.Next() {
+		var p Posting
+		var compensatingTxID sql.NullInt32
+		if err := rows.Scan(&p.SourceTxID, &p.Payer, &p.Amount, &p.Timestamp, &compensatingTxID); err != nil {
+			return nil, fmt.Errorf("sqlStore.ListPostings() %w", err)
+		}
+		p.CompensatingTxID = compensatingTxID.Int32
+		result = append(result, p)
+	}
+	return result, rows.Err()
+}
+
+func (s *sqlStore) RecordAudit(rec AuditRecord) error {
+	_, err := s.db.Exec(
+		`INSERT INTO audit_log (transaction_id, created_at, reversed_by, reason) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (transaction_id) DO UPDATE SET reversed_by = EXCLUDED.reversed_by, reason = EXCLUDED.reason`,
+		rec.TransactionID, rec.CreatedAt, rec.ReversedBy, rec.Reason,
+	)
+	if err != nil {
+		return fmt.Errorf("sqlStore.RecordAudit() %w", err)
+	}
+	return nil
+}
+
+func (s *sqlStore) GetAuditTrail(txID int32) (AuditRecord, bool, error) {
+	row := s.db.QueryRow(`SELECT transaction_id, created_at, reversed_by, reason FROM audit_log WHERE transaction_id = $1`, txID)
+	var rec AuditRecord
+	if err := row.Scan(&rec.TransactionID, &rec.CreatedAt, &rec.ReversedBy, &rec.Reason); err != nil {
+		if err == sql.ErrNoRows {
+			return AuditRecord{}, false, nil
+		}
+		return AuditRecord{}, false, fmt.Errorf("sqlStore.GetAuditTrail() %w", err)
+	}
+	return rec, true, nil
+}
+
+func (s *sqlStore) Reset() {
+	s.db.Exec(`DELETE FROM audit_log`)
+	s.db.Exec(`DELETE FROM spend_journal`)
+	s.db.Exec(`DELETE FROM spend_log`)
+	s.db.Exec(`DELETE FROM transactions`)
+}
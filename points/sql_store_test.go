@@ -0,0 +1,416 @@
+package points
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// This sandbox has no network access to pull in a real sqlite or go-sqlmock
+// dependency, so fakeSQLDriver stands in for one: a minimal
+// database/sql/driver.Driver, built only from the standard library, that
+// recognizes the exact statements sql_store.go issues and serves them from
+// in-memory tables. It exists solely to give sqlStore - previously
+// completely untested - the same kind of round-trip coverage
+// journal_store_test.go gives journalStore.
+
+func init() {
+	sql.Register("fakesql", &fakeSQLDriver{})
+}
+
+type fakeSQLDriver struct{}
+
+func (fakeSQLDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{
+		spendLog:    map[int64]int64{},
+		auditLog:    map[int64]fakeAuditRow{},
+		appliedMigs: map[int64]bool{},
+		nextTxID:    1,
+		nextPostID:  1,
+	}, nil
+}
+
+type fakeTxRow struct {
+	id            int64
+	payer         string
+	points        int64
+	timestamp     time.Time
+	userInitiated bool
+}
+
+type fakeAuditRow struct {
+	createdAt  time.Time
+	reversedBy *int64
+	reason     string
+}
+
+type fakePostingRow struct {
+	id               int64
+	sourceTxID       int64
+	payer            string
+	amount           int64
+	timestamp        time.Time
+	compensatingTxID *int64
+}
+
+// fakeConn implements driver.Conn plus the context-aware Execer/Queryer
+// interfaces, dispatching on the literal query text sql_store.go and
+// migrations.go issue. It isn't a general-purpose SQL engine - it only
+// understands those specific statements.
+type fakeConn struct {
+	mu sync.Mutex
+
+	transactions []fakeTxRow
+	nextTxID     int64
+
+	spendLog map[int64]int64
+
+	postings   []fakePostingRow
+	nextPostID int64
+
+	auditLog map[int64]fakeAuditRow
+
+	appliedMigs map[int64]bool
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fakeConn: transactions are not supported")
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.exec(s.query, args)
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.query(s.query, args)
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.exec(query, namedToOrdinal(args))
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.query(query, namedToOrdinal(args))
+}
+
+func namedToOrdinal(named []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(named))
+	for _, nv := range named {
+		values[nv.Ordinal-1] = nv.Value
+	}
+	return values
+}
+
+type fakeResult struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r fakeResult) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+// exec handles every statement sqlStore sends via db.Exec - i.e. one that
+// doesn't need to return rows.
+func (c *fakeConn) exec(query string, args []driver.Value) (driver.Result, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case strings.Contains(query, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+		return fakeResult{}, nil
+	case strings.Contains(query, "CREATE TABLE") || strings.Contains(query, "ALTER TABLE"):
+		// Every other migration: this fake driver keeps its tables as Go
+		// slices/maps rather than real SQL tables, so there's no schema to
+		// apply - just acknowledge the statement.
+		return fakeResult{}, nil
+	case strings.Contains(query, "INSERT INTO schema_migrations"):
+		c.appliedMigs[args[0].(int64)] = true
+		return fakeResult{rowsAffected: 1}, nil
+	case strings.Contains(query, "INSERT INTO spend_log"):
+		txID := args[0].(int64)
+		amount := args[1].(int64)
+		c.spendLog[txID] += amount
+		return fakeResult{rowsAffected: 1}, nil
+	case strings.Contains(query, "INSERT INTO spend_journal"):
+		c.postings = append(c.postings, fakePostingRow{
+			id:               c.nextPostID,
+			sourceTxID:       args[0].(int64),
+			payer:            args[1].(string),
+			amount:           args[2].(int64),
+			timestamp:        args[3].(time.Time),
+			compensatingTxID: nullableInt64(args[4]),
+		})
+		c.nextPostID++
+		return fakeResult{rowsAffected: 1}, nil
+	case strings.Contains(query, "INSERT INTO audit_log"):
+		// Mirror the real ON CONFLICT clause: created_at isn't in its DO
+		// UPDATE SET list, so an existing row keeps its original value.
+		txID := args[0].(int64)
+		createdAt := args[1].(time.Time)
+		if existing, ok := c.auditLog[txID]; ok {
+			createdAt = existing.createdAt
+		}
+		c.auditLog[txID] = fakeAuditRow{
+			createdAt:  createdAt,
+			reversedBy: nullableInt64(args[2]),
+			reason:     args[3].(string),
+		}
+		return fakeResult{rowsAffected: 1}, nil
+	case strings.Contains(query, "DELETE FROM audit_log"):
+		c.auditLog = map[int64]fakeAuditRow{}
+		return fakeResult{}, nil
+	case strings.Contains(query, "DELETE FROM spend_journal"):
+		c.postings = nil
+		c.nextPostID = 1
+		return fakeResult{}, nil
+	case strings.Contains(query, "DELETE FROM spend_log"):
+		c.spendLog = map[int64]int64{}
+		return fakeResult{}, nil
+	case strings.Contains(query, "DELETE FROM transactions"):
+		c.transactions = nil
+		c.nextTxID = 1
+		return fakeResult{}, nil
+	}
+	return nil, errors.New("fakeConn.exec: unrecognized query: " + query)
+}
+
+// query handles every statement sqlStore sends via db.Query or db.QueryRow.
+func (c *fakeConn) query(query string, args []driver.Value) (driver.Rows, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case strings.Contains(query, "SELECT version FROM schema_migrations"):
+		rows := &fakeRows{columns: []string{"version"}}
+		for v := range c.appliedMigs {
+			rows.data = append(rows.data, []driver.Value{v})
+		}
+		return rows, nil
+	case strings.Contains(query, "INSERT INTO transactions") && strings.Contains(query, "RETURNING id"):
+		id := c.nextTxID
+		c.nextTxID++
+		c.transactions = append(c.transactions, fakeTxRow{
+			id:            id,
+			payer:         args[0].(string),
+			points:        args[1].(int64),
+			timestamp:     args[2].(time.Time),
+			userInitiated: args[3].(bool),
+		})
+		return &fakeRows{columns: []string{"id"}, data: [][]driver.Value{{id}}}, nil
+	case strings.Contains(query, "SELECT id, payer, points, timestamp, user_initiated FROM transactions"):
+		sorted := append([]fakeTxRow{}, c.transactions...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].timestamp.Before(sorted[j].timestamp) })
+		rows := &fakeRows{columns: []string{"id", "payer", "points", "timestamp", "user_initiated"}}
+		for _, t := range sorted {
+			rows.data = append(rows.data, []driver.Value{t.id, t.payer, t.points, t.timestamp, t.userInitiated})
+		}
+		return rows, nil
+	case strings.Contains(query, "SELECT payer, SUM(points) FROM transactions"):
+		totals := map[string]int64{}
+		for _, t := range c.transactions {
+			totals[t.payer] += t.points
+		}
+		rows := &fakeRows{columns: []string{"payer", "sum"}}
+		for payer, total := range totals {
+			rows.data = append(rows.data, []driver.Value{payer, total})
+		}
+		return rows, nil
+	case strings.Contains(query, "SELECT transaction_id, spent_points FROM spend_log"):
+		rows := &fakeRows{columns: []string{"transaction_id", "spent_points"}}
+		for txID, spent := range c.spendLog {
+			rows.data = append(rows.data, []driver.Value{txID, spent})
+		}
+		return rows, nil
+	case strings.Contains(query, "SELECT source_tx_id, payer, amount, timestamp, compensating_tx_id FROM spend_journal"):
+		sorted := append([]fakePostingRow{}, c.postings...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].id < sorted[j].id })
+		rows := &fakeRows{columns: []string{"source_tx_id", "payer", "amount", "timestamp", "compensating_tx_id"}}
+		for _, p := range sorted {
+			rows.data = append(rows.data, []driver.Value{p.sourceTxID, p.payer, p.amount, p.timestamp, int64PtrToValue(p.compensatingTxID)})
+		}
+		return rows, nil
+	case strings.Contains(query, "SELECT transaction_id, created_at, reversed_by, reason FROM audit_log"):
+		txID := args[0].(int64)
+		rows := &fakeRows{columns: []string{"transaction_id", "created_at", "reversed_by", "reason"}}
+		if rec, ok := c.auditLog[txID]; ok {
+			rows.data = append(rows.data, []driver.Value{txID, rec.createdAt, int64PtrToValue(rec.reversedBy), rec.reason})
+		}
+		return rows, nil
+	}
+	return nil, errors.New("fakeConn.query: unrecognized query: " + query)
+}
+
+func nullableInt64(v driver.Value) *int64 {
+	if v == nil {
+		return nil
+	}
+	n := v.(int64)
+	return &n
+}
+
+func int64PtrToValue(p *int64) driver.Value {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+// fakeRows implements driver.Rows over a pre-computed in-memory result set.
+type fakeRows struct {
+	columns []string
+	data    [][]driver.Value
+	pos     int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.data) {
+		return io.EOF
+	}
+	copy(dest, r.data[r.pos])
+	r.pos++
+	return nil
+}
+
+func newFakeSQLStore(t *testing.T) Store {
+	t.Helper()
+	db, err := sql.Open("fakesql", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	s, err := NewSQLStore(db)
+	if err != nil {
+		t.Fatalf("NewSQLStore: %v", err)
+	}
+	return s
+}
+
+// TestSQLStoreRoundTrip replays the same save/spend/reverse/verify sequence
+// TestVerifyPassesAfterReversingASpend exercises against the default memoryStore, but
+// against a sqlStore, confirming its migrations, upsert semantics, and
+// nullable-column handling round-trip correctly through database/sql.
+func TestSQLStoreRoundTrip(t *testing.T) {
+	original := defaultStore
+	SetStore(newFakeSQLStore(t))
+	defer SetStore(original)
+
+	ResetTransactions()
+
+	tr, err := NewTransaction("DANNON", 1000, "2020-10-31T15:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// SpendPoints exercises RecordSpend and AppendPosting itself, recording
+	// the spend against tr and saving a new userInitiated debit Transaction.
+	spent, err := tr.SpendPoints(300)
+	if err != nil {
+		t.Fatalf("SpendPoints: %v", err)
+	}
+	if got, want := spent, int32(300); got != want {
+		t.Errorf("SpendPoints: got %v; want %v", got, want)
+	}
+
+	spendLog, err := defaultStore.GetSpendLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := spendLog[tr.ID], int32(300); got != want {
+		t.Errorf("GetSpendLog: got %v; want %v", got, want)
+	}
+
+	// RecordSpend's ON CONFLICT upsert should accumulate, not overwrite.
+	if err := defaultStore.RecordSpend(tr.ID, 50); err != nil {
+		t.Fatalf("RecordSpend: %v", err)
+	}
+	spendLog, err = defaultStore.GetSpendLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := spendLog[tr.ID], int32(350); got != want {
+		t.Errorf("GetSpendLog after second RecordSpend: got %v; want %v (upsert should accumulate)", got, want)
+	}
+
+	postings, err := defaultStore.ListPostings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postings) != 1 || postings[0].CompensatingTxID == 0 {
+		t.Fatalf("ListPostings: got %+v; want one Posting with a non-zero CompensatingTxID", postings)
+	}
+	debitTxID := postings[0].CompensatingTxID
+
+	// Reverse the spend (rather than the original award) so the reversal
+	// also exercises reversePostingsLocked's ListPostings/AppendPosting
+	// round trip, including the nullable compensating_tx_id column, which
+	// is set on both the original Posting and the reversal's.
+	compensating, err := ReverseTransaction(debitTxID, "reversal test")
+	if err != nil {
+		t.Fatalf("ReverseTransaction: %v", err)
+	}
+
+	audit, ok, err := GetAuditTrail(debitTxID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("GetAuditTrail: expected a record for the reversed transaction")
+	}
+	if audit.ReversedBy == nil || *audit.ReversedBy != compensating.ID {
+		t.Errorf("GetAuditTrail: got ReversedBy %v; want %v", audit.ReversedBy, compensating.ID)
+	}
+	if got, want := audit.Reason, "reversal test"; got != want {
+		t.Errorf("GetAuditTrail: got reason %v; want %v", got, want)
+	}
+
+	// RecordAudit's ON CONFLICT clause only updates reversed_by and reason;
+	// a second call for the same TransactionID should leave CreatedAt as it
+	// was on the first.
+	if err := defaultStore.RecordAudit(AuditRecord{TransactionID: debitTxID, CreatedAt: time.Now(), Reason: "overwritten"}); err != nil {
+		t.Fatalf("RecordAudit (second call): %v", err)
+	}
+	reaudit, _, err := defaultStore.GetAuditTrail(debitTxID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reaudit.CreatedAt.Equal(audit.CreatedAt) {
+		t.Errorf("RecordAudit upsert changed CreatedAt: got %v; want it unchanged at %v", reaudit.CreatedAt, audit.CreatedAt)
+	}
+
+	totals, err := GetPayerTotals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := totals["DANNON"], int32(1000); got != want {
+		t.Errorf("reversing the spend didn't restore the payer's balance: got %v; want %v", got, want)
+	}
+
+	if err := Verify(); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package points
+
+import "sync"
+
+// stateMu guards every read and write that touches the active Store so that
+// the sequence "check available -> check payer floor -> append spend
+// transaction -> update totals" in SpendPoints is atomic with respect to
+// concurrent requests. Reads take the read lock; Save and SpendPoints take
+// the write lock for their whole duration (including any transactions they
+// append as a side effect), so callers must never acquire it twice on the
+// same goroutine.
+var stateMu sync.RWMutex
+
+// Store is the persistence interface used by the points package to save and
+// retrieve Transaction, PayerTotals, and SpendLog data. It is deliberately
+// narrow so that alternative backends (in-memory, SQL, etc.) can be swapped
+// in at startup without any other package needing to change.
+type Store interface {
+	// SaveTransaction persists t, assigning it a unique id, and returns the
+	// persisted copy (including the assigned id).
+	SaveTransaction(t Transaction) (Transaction, error)
+	// ListTransactionsByTimestamp returns every known Transaction ordered
+	// from oldest to newest.
+	ListTransactionsByTimestamp() ([]Transaction, error)
+	// GetPayerTotals returns the current point balance for every payer.
+	GetPayerTotals() (PayerTotals, error)
+	// GetSpendLog returns how many points have been spent from each
+	// Transaction, keyed by Transaction id.
+	GetSpendLog() (SpendLog, error)
+	// RecordSpend marks amount additional points as spent from the
+	// Transaction identified by txID. It does not itself adjust
+	// PayerTotals; callers are expected to persist the compensating
+	// negative Transaction (via SaveTransaction) that does.
+	RecordSpend(txID int32, amount int32) error
+	// AppendPosting appends an immutable Posting to the spend journal,
+	// independently of RecordSpend and SaveTransaction, so the journal can
+	// later be replayed to verify PayerTotals hasn't drifted.
+	AppendPosting(p Posting) error
+	// ListPostings returns every Posting ever appended, oldest first.
+	ListPostings() ([]Posting, error)
+	// RecordAudit upserts the audit trail entry for a single Transaction,
+	// keyed by its AuditRecord.TransactionID.
+	RecordAudit(rec AuditRecord) error
+	// GetAuditTrail returns the audit trail entry for the Transaction
+	// identified by txID, if one has been recorded.
+	GetAuditTrail(txID int32) (AuditRecord, bool, error)
+	// Reset wipes all persisted state. It exists primarily to give tests a
+	// clean slate between cases.
+	Reset()
+}
+
+// defaultStore is the Store implementation used by the package-level
+// convenience functions (GetTransactions, Save, SpendPoints, etc.) unless
+// SetStore is called to inject a different backend.
+var defaultStore Store = NewMemoryStore()
+
+// SetStore replaces the package's active Store. It should be called once,
+// during startup, before any requests are served.
+func SetStore(s Store) {
+	defaultStore = s
+}
@@ -20,7 +20,9 @@ func (pt PayerTotals) ToPayerBalances() []PayerBalance {
 
 // GetPayerTotals returns a PayerTotal object representing the current balance for each payer.
 func GetPayerTotals() (PayerTotals, error) {
-	return payerTotals, nil
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	return defaultStore.GetPayerTotals()
 }
 
 // TotalAvailable returns the sum of all points for all payers.
@@ -2,7 +2,7 @@ package points
 
 import (
 	"fmt"
-	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,18 +12,9 @@ type Transaction struct {
 	Points        int32     `json:"points"`
 	Timestamp     time.Time `json:"timestamp"`
 	userInitiated bool
-	id            int32
+	ID            int32 `json:"id"`
 }
 
-// allTransactions is a top-scope variable acting as an in-memory database of transactions.
-var allTransactions = []Transaction{}
-
-// payerTotals is a top-scope variable acting as an in-memory summary of total points per payer.
-var payerTotals = PayerTotals{}
-
-// spentTransactions is a top-scope variable acting as an in-memory, time efficient reference map storing which Transactions have been spent.
-var spentTransactions = SpendLog{}
-
 // ByTimestamp is an alias type for a slice of Transaction objects that can be used with the sort package to improve readability.
 type ByTimestamp []Transaction
 
@@ -36,9 +27,10 @@ func (t ByTimestamp) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
 // Less returns a boolean representing wether the Transaction element at index i is "less than" the element at index j as required by sort.Sort().
 func (t ByTimestamp) Less(i, j int) bool { return (t[i].Timestamp).Before(t[j].Timestamp) }
 
-// randomUniqueIDGenerator is a type dedicated to creating random unique IDs.
+// randomUniqueIDGenerator is a type dedicated to creating unique IDs. Its
+// counter is a sync/atomic int32 rather than a mutex-guarded one so that
+// ID() remains safe to call even outside of stateMu's protection.
 type randomUniqueIDGenerator struct {
-	sync.Mutex
 	id int32
 }
 
@@ -47,25 +39,21 @@ var transactionUIDs randomUniqueIDGenerator
 
 // ID is a method for the randomUniqueIDGenerator struct that creates incrementing ID values.
 func (rui *randomUniqueIDGenerator) ID() int32 {
-	rui.Lock()
-	defer rui.Unlock()
-
-	id := rui.id
-	rui.id++
-	return id
+	return atomic.AddInt32(&rui.id, 1) - 1
 }
 
-// ResetTransactions will wipe all global variables to emulate a fresh "database".
+// ResetTransactions will wipe the active Store to emulate a fresh "database".
 func ResetTransactions() {
-	allTransactions = []Transaction{}
-	payerTotals = PayerTotals{}
-	spentTransactions = SpendLog{}
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	defaultStore.Reset()
 }
 
-// GetTransactions returns a slice of all the currently available Transaction objects (global allTransactions variable).
-// Consider this a placeholder for a database query.
+// GetTransactions returns a slice of all the currently available Transaction objects, oldest first, from the active Store.
 func GetTransactions() ([]Transaction, error) {
-	return allTransactions, nil
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	return defaultStore.ListTransactionsByTimestamp()
 }
 
 // NewTransaction is a constructor for the Transaction struct (not user initiated) that will attempt to convert a string timestamp to a time.Time object.
@@ -76,7 +64,7 @@ func NewTransaction(payer string, points int32, timestamp string) (*Transaction,
 	userInit := false
 	t, err := time.Parse(time.RFC3339, timestamp)
 	if err != nil {
-		return nil, fmt.Errorf("invalid timestamp format: got %v; expected time.RFC3339 format", timestamp)
+		return nil, E("points.NewTransaction", KindValidation, fmt.Errorf("invalid timestamp format: got %v; expected time.RFC3339 format", timestamp), payer)
 	}
 	result := Transaction{
 		Payer:         payer,
@@ -102,20 +90,30 @@ func (t *Transaction) Validate() error {
 		missingAttributes = append(missingAttributes, "timestamp")
 	}
 	if len(missingAttributes) > 0 {
-		return fmt.Errorf("Validate() Invalid input - missing attributes: %v", missingAttributes)
+		return E("points.Validate", KindValidation, fmt.Errorf("missing attributes: %v", missingAttributes), t.Payer)
 	}
 	return nil
 }
 
-// Save operates on a Transaction object, adding it to the end of the global allTransactions slice.
-// Consider this a placeholder for a database query.
+// Save validates t and persists it to the active Store, assigning it a unique id.
 func (t *Transaction) Save() error {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	return t.saveLocked()
+}
+
+// saveLocked performs the work of Save without acquiring stateMu. It exists
+// so that SpendPoints, which already holds the write lock for the duration
+// of its compound check-then-act sequence, can persist its compensating
+// transaction without deadlocking against itself.
+func (t *Transaction) saveLocked() error {
 	if err := t.Validate(); err != nil {
 		return err
 	}
-	// Give this transaction a unique ID
-	t.id = transactionUIDs.ID()
-	allTransactions = append(allTransactions, *t)
-	payerTotals[t.Payer] += t.Points
+	saved, err := defaultStore.SaveTransaction(*t)
+	if err != nil {
+		return E("points.Save", KindStorage, err, t.Payer)
+	}
+	*t = saved
 	return nil
 }
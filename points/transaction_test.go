@@ -82,7 +82,7 @@ func testFuncForSpendPoints(tr Transaction, points int32, expectedRemaining int3
 		beforeTotal := pt[tr.Payer]
 
 		st, _ := GetSpentTransactions()
-		beforeSpent := st[tr.id]
+		beforeSpent := st[tr.ID]
 		expectedActualSpend := tr.Points - beforeSpent - expectedRemaining
 
 		spent, err := tr.SpendPoints(points)
@@ -96,7 +96,7 @@ func testFuncForSpendPoints(tr Transaction, points int32, expectedRemaining int3
 		if got, want := spent, expectedActualSpend; got != want {
 			t.Errorf("method should return the number of points used from a transaction: got %v expected %v", got, want)
 		}
-		if got, want := st[tr.id], beforeSpent+spent; got != want {
+		if got, want := st[tr.ID], beforeSpent+spent; got != want {
 			t.Errorf("method should update spentTransactions with amount spent: got %v expected %v", got, want)
 		}
 		if got, want := len(at), beforeLength+expectedLengthIncrease; got != want {